@@ -0,0 +1,267 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Normalizer is a function that normalizes an output before matching.
+type Normalizer func(string) string
+
+// normalize returns the string normalized by all the normalizers, in order.
+func normalize(s string, normalizers []Normalizer) string {
+	for _, f := range normalizers {
+		s = f(s)
+	}
+	return s
+}
+
+// StripANSI returns the string with all the ANSI escape sequences removed.
+func StripANSI(s string) string {
+	return replaceANSI(s, func(string, string, byte) string { return "" })
+}
+
+// SymbolizeANSI returns the string with the ANSI SGR sequences replaced by
+// tags, such as "<bold><red>value</red></bold>". Colors are named "red",
+// "bright-red", "bg-red", "color-208" and "#ff8700" for the 256 and 24-bit
+// color palettes. Tags are only opened when text follows, so that no empty tag
+// pairs are written. The other ANSI escape sequences are left unchanged, to be
+// interpreted by Snapshot or removed by StripANSI.
+func SymbolizeANSI(s string) string {
+	var b strings.Builder
+	t := new(tags)
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], 0x1b)
+		if j < 0 {
+			j = len(s) - i
+		}
+		if j > 0 {
+			t.flush(&b, true) // open the tags only when text follows
+			b.WriteString(s[i : i+j])
+			i += j
+			continue
+		}
+
+		n, params, final := ansiSequence(s[i:])
+		if final == 'm' {
+			t.sgr(params)
+		} else {
+			b.WriteString(s[i : i+n])
+		}
+		i += n
+	}
+	t.flush(&b, false)
+	return b.String()
+}
+
+// Snapshot returns the final screen content produced by writing the string to
+// a virtual terminal screen of unbounded size, which interprets carriage
+// returns and cursor movements such as the ones used to draw a progress bar.
+// The ANSI SGR sequences are ignored.
+func Snapshot(s string) string {
+	screen := newScreen(0, 0)
+	screen.Write([]byte(s))
+	return screen.String()
+}
+
+// replaceANSI returns the string with each ANSI escape sequence replaced by the
+// value returned by the replace function. Control sequences are passed with
+// their parameters and final byte, other sequences with a zero final byte.
+func replaceANSI(s string, replace func(sequence, params string, final byte) string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i:], 0x1b)
+		if j < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		b.WriteString(s[i : i+j])
+		i += j
+
+		n, params, final := ansiSequence(s[i:])
+		b.WriteString(replace(s[i:i+n], params, final))
+		i += n
+	}
+	return b.String()
+}
+
+// ansiSequence returns the length of the escape sequence at the start of the
+// string and, for control sequences, the parameters and the final byte.
+func ansiSequence(s string) (n int, params string, final byte) {
+	if len(s) < 2 {
+		return len(s), "", 0
+	}
+
+	switch s[1] {
+
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if c := s[i]; c >= 0x40 && c <= 0x7e {
+				return i + 1, s[2:i], c
+			}
+		}
+
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1, "", 0
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2, "", 0
+			}
+		}
+
+	default:
+		return 2, "", 0
+	}
+
+	return len(s), "", 0 // incomplete
+}
+
+// colors holds the names of the basic colors.
+var colors = [8]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// attributes holds the tag names of the SGR attributes by code.
+var attributes = map[int]string{
+	1: "bold", 2: "dim", 3: "italic", 4: "underline",
+	5: "blink", 7: "reverse", 8: "hidden", 9: "strike",
+}
+
+// resets holds the tag names closed by the SGR reset codes.
+var resets = map[int][]string{
+	22: {"bold", "dim"}, 23: {"italic"}, 24: {"underline"}, 25: {"blink"},
+	27: {"reverse"}, 28: {"hidden"}, 29: {"strike"}, 39: {"fg"}, 49: {"bg"},
+}
+
+// tag represents an open tag of a kind, which is either the name of an
+// attribute or "fg" and "bg" for colors.
+type tag struct {
+	kind, name string
+}
+
+// tags represents the stack of open tags, as set by the SGR sequences, and the
+// stack of tags written so far.
+type tags struct {
+	open    []tag
+	written []tag
+}
+
+// sgr updates the open tags with the attributes changed by the SGR sequence
+// with the specified parameters.
+func (t *tags) sgr(params string) {
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i])
+
+		switch {
+
+		case code == 0:
+			t.close("")
+
+		case attributes[code] != "":
+			t.push(tag{attributes[code], attributes[code]})
+
+		case resets[code] != nil:
+			for _, kind := range resets[code] {
+				t.close(kind)
+			}
+
+		case 30 <= code && code <= 37:
+			t.push(tag{"fg", colors[code-30]})
+
+		case 90 <= code && code <= 97:
+			t.push(tag{"fg", "bright-" + colors[code-90]})
+
+		case 40 <= code && code <= 47:
+			t.push(tag{"bg", "bg-" + colors[code-40]})
+
+		case 100 <= code && code <= 107:
+			t.push(tag{"bg", "bg-bright-" + colors[code-100]})
+
+		case code == 38 || code == 48:
+			name, n := extendedColor(codes[i+1:])
+			i += n
+			if code == 48 {
+				t.push(tag{"bg", "bg-" + name})
+			} else {
+				t.push(tag{"fg", name})
+			}
+		}
+	}
+}
+
+// extendedColor returns the name of the 256 or 24-bit color encoded by the
+// arguments following an extended color code and the number of arguments used.
+func extendedColor(args []string) (string, int) {
+	n := func(i int) int {
+		if i < len(args) {
+			v, _ := strconv.Atoi(args[i])
+			return v
+		}
+		return 0
+	}
+
+	switch n(0) {
+
+	case 5:
+		return "color-" + strconv.Itoa(n(1)), 2
+
+	case 2:
+		return fmt.Sprintf("#%02x%02x%02x", n(1), n(2), n(3)), 4
+	}
+
+	return "color", len(args)
+}
+
+// push opens the tag, replacing any open tag of the same kind.
+func (t *tags) push(open tag) {
+	for _, o := range t.open {
+		if o == open {
+			return // already open
+		}
+	}
+	t.close(open.kind)
+	t.open = append(t.open, open)
+}
+
+// close closes the open tag of the specified kind, or all the open tags if the
+// kind is empty.
+func (t *tags) close(kind string) {
+	if kind == "" {
+		t.open = nil
+		return
+	}
+	for i := len(t.open) - 1; i >= 0; i-- {
+		if t.open[i].kind == kind {
+			t.open = append(t.open[:i:i], t.open[i+1:]...)
+			return
+		}
+	}
+}
+
+// flush writes the tags that close the written tags no longer open and, if
+// the reopen flag is set, the tags that open the ones not yet written. The tags
+// written after a closed one are closed and reopened, to keep them nested.
+func (t *tags) flush(b *strings.Builder, reopen bool) {
+	k := 0
+	for k < len(t.open) && k < len(t.written) && t.open[k] == t.written[k] {
+		k++
+	}
+	for j := len(t.written) - 1; j >= k; j-- {
+		b.WriteString("</" + t.written[j].name + ">")
+	}
+	t.written = t.written[:k]
+
+	if reopen {
+		for _, o := range t.open[k:] {
+			b.WriteString("<" + o.name + ">")
+		}
+		t.written = append(t.written, t.open[k:]...)
+	}
+}
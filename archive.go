@@ -0,0 +1,238 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/larhun/golden/internal/txtar"
+)
+
+// archiveExts holds the extensions of the supported archive formats.
+var archiveExts = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+// archiveExt returns the archive extension of the named file, or an empty
+// string if the file is not a supported archive.
+func archiveExt(name string) string {
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// entry represents an archive entry.
+type entry struct {
+	name string      // entry name
+	mode os.FileMode // permission bits
+	data string      // content
+}
+
+// readArchive returns the regular and symbolic link entries of the archive
+// with the specified extension, sorted by name. The entries of nested archives
+// are included with the nested archive name as prefix.
+func readArchive(ext, data string) ([]entry, error) {
+	var entries []entry
+
+	add := func(name string, mode os.FileMode, r io.Reader) error {
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		if nested := archiveExt(name); nested != "" {
+			list, err := readArchive(nested, string(content))
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			for _, e := range list {
+				e.name = name + "/" + e.name
+				entries = append(entries, e)
+			}
+			return nil
+		}
+
+		entries = append(entries, entry{name, mode, string(content)})
+		return nil
+	}
+
+	switch ext {
+
+	case ".zip":
+		r, err := zip.NewReader(strings.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range r.File {
+			mode := f.Mode()
+			if !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			if mode&os.ModeSymlink != 0 {
+				err = add(f.Name, mode.Perm(), io.MultiReader(strings.NewReader("-> "), rc))
+			} else {
+				err = add(f.Name, mode.Perm(), rc)
+			}
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		var r io.Reader = strings.NewReader(data)
+		if ext != ".tar" {
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			r = gz
+		}
+
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			mode := os.FileMode(h.Mode).Perm()
+			switch h.Typeflag {
+			case tar.TypeReg, tar.TypeRegA:
+				err = add(h.Name, mode, tr)
+			case tar.TypeSymlink:
+				err = add(h.Name, mode, strings.NewReader("-> "+h.Linkname))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	return entries, nil
+}
+
+// listing returns the names and contents of the entries as listed by a gold
+// master. Binary contents are reported by their size and digest. If modes is
+// true, the entry modes are added to the names.
+func listing(entries []entry, modes bool) (names []string, files map[string]string) {
+	files = map[string]string{}
+	for _, e := range entries {
+		name := e.name
+		if modes {
+			name += fmt.Sprintf(" (%04o)", e.mode)
+		}
+
+		data := e.data
+		if isBinary(data) {
+			data = fmt.Sprintf("binary: %d bytes, sha256 %x", len(data), sha256.Sum256([]byte(data)))
+		}
+
+		names = append(names, name)
+		files[name] = data
+	}
+	return names, files
+}
+
+// formatListing returns the listing in the txtar format. Contents that would be
+// interpreted as smart strings are escaped and a newline, ignored when parsed,
+// is added to each content.
+func formatListing(names []string, files map[string]string) string {
+	a := new(txtar.Archive)
+	for _, name := range names {
+		a.Files = append(a.Files, txtar.File{Name: name, Data: literal(files[name]) + "\n"})
+	}
+	return txtar.Format(a)
+}
+
+// compareListing returns the differences between the got files and the want
+// listing. The content of each wanted entry, without the ending newline, is a
+// smart validation string.
+func compareListing(names []string, files map[string]string, want string) []string {
+	var problems []string
+
+	wanted := map[string]bool{}
+	for _, f := range txtar.Parse(want).Files {
+		wanted[f.Name] = true
+		data, ok := files[f.Name]
+		if !ok {
+			problems = append(problems, "missing entry: "+f.Name)
+			continue
+		}
+		wantData := strings.TrimSuffix(f.Data, "\n")
+		if kind, ok := smart(data, wantData); !ok {
+			name := "entry " + f.Name
+			if kind != "" {
+				name += " " + kind
+			}
+			problems = append(problems, format(name, data, wantData))
+		}
+	}
+
+	for _, name := range names {
+		if !wanted[name] {
+			problems = append(problems, "unexpected entry: "+name)
+		}
+	}
+
+	return problems
+}
+
+// matchArchive tests if the entries of the archive data with the specified
+// extension match the entries listed by the gold master. If not, accumulates
+// an error.
+func (m *match) matchArchive(data, ext string, modes bool) {
+	name := "File golden" + ext + ".txtar"
+
+	entries, err := readArchive(ext, data)
+	if err != nil {
+		m.messages = append(m.messages, "File archive read error:\n"+err.Error())
+		return
+	}
+
+	names, files := listing(entries, modes)
+	got := formatListing(names, files)
+	want, ok := m.getGolden(name, ext+".txtar", got)
+	if !ok {
+		return // file error
+	}
+
+	c := check{
+		Name:    "File",
+		Matcher: "archive",
+		Got:     got,
+		Want:    want,
+		File:    m.goldenFile(ext + ".txtar"),
+
+		diffable: true,
+	}
+
+	problems := compareListing(names, files, want)
+	if c.Pass = len(problems) == 0; !c.Pass {
+		c.Message = name + " match error:\n" + strings.Join(problems, "\n")
+	}
+
+	m.setActual(name, c.File, got, c.Pass)
+	m.addCheck(c)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// Benchmark benchmarks the specified command by running a sub-benchmark for
+// each listed case, which reports the time and the allocations per run. The
+// outputs are tested as by Test on the first run only, and the case is not
+// benchmarked if failed. The outputs of the other runs are discarded.
+func Benchmark(b *testing.B, command Runner, testCases []Case) {
+	b.Helper()
+
+	for _, tc := range testCases {
+		tested := false
+		b.Run(tc.Name, func(b *testing.B) {
+			if !tested {
+				tested = true
+				testCase(b, command, tc)
+			}
+			if b.Failed() {
+				return
+			}
+
+			stdin, hasStdin := command.(StdinSetter)
+			command.SetStdout(ioutil.Discard)
+			command.SetStderr(ioutil.Discard)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if hasStdin {
+					stdin.SetStdin(strings.NewReader(tc.Stdin))
+				}
+				command.Run(tc.Args)
+			}
+		})
+	}
+}
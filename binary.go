@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// hexRows holds the number of rows of the hex dumps around the first
+// difference of binary strings.
+const hexRows = 4
+
+// isBinary reports whether the string is binary, that is, not valid UTF-8 or
+// containing a NUL byte.
+func isBinary(s string) bool {
+	return !utf8.ValidString(s) || strings.IndexByte(s, 0) >= 0
+}
+
+// formatBinary returns a formatted error message that reports the test name,
+// the size and SHA-256 digest of the got and want binary values and the hex
+// dumps around their first difference.
+func formatBinary(name, got, want string) string {
+	m := new(message)
+
+	m.WriteString(name)
+	m.WriteString(" match error (binary):")
+	m.WriteString(fmt.Sprintf("\ngot: %d bytes, sha256 %x", len(got), sha256.Sum256([]byte(got))))
+	m.WriteString(fmt.Sprintf("\nwant: %d bytes, sha256 %x", len(want), sha256.Sum256([]byte(want))))
+
+	i := 0
+	for i < len(got) && i < len(want) && got[i] == want[i] {
+		i++
+	}
+	m.WriteString(fmt.Sprintf("\nfirst difference at offset %d (0x%x)", i, i))
+
+	start := (i/16 - 1) * 16
+	if start < 0 {
+		start = 0
+	}
+	end := start + hexRows*16
+
+	m.WriteString("\ngot:")
+	m.WriteIndent(hexDump(got, start, end))
+	m.WriteString("\nwant:")
+	m.WriteIndent(hexDump(want, start, end))
+
+	return m.String()
+}
+
+// hexDump returns the hex dump of the bytes of the string from the start
+// offset, a multiple of 16, to the end offset. Each row reports the offset,
+// up to 16 bytes in hexadecimal and their printable characters.
+func hexDump(s string, start, end int) string {
+	if end > len(s) {
+		end = len(s)
+	}
+	if start >= end {
+		return "(end of data)"
+	}
+
+	var rows []string
+	for offset := start; offset < end; offset += 16 {
+		row := s[offset:]
+		if len(row) > 16 {
+			row = row[:16]
+		}
+
+		var hex, text strings.Builder
+		for j := 0; j < 16; j++ {
+			if j == 8 {
+				hex.WriteByte(' ')
+			}
+			if j >= len(row) {
+				hex.WriteString("   ")
+				continue
+			}
+			fmt.Fprintf(&hex, " %02x", row[j])
+			if c := row[j]; c >= 0x20 && c < 0x7f {
+				text.WriteByte(c)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+
+		rows = append(rows, fmt.Sprintf("%08x %s  |%s|", offset, hex.String(), text.String()))
+	}
+	return strings.Join(rows, "\n")
+}
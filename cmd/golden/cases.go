@@ -0,0 +1,271 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/larhun/golden"
+	"github.com/larhun/golden/internal/txtar"
+	"github.com/larhun/golden/internal/yaml"
+)
+
+// suite represents the test cases of a case file.
+type suite struct {
+	Name    string     `json:"-"`       // suite name, from the file path
+	Program string     `json:"program"` // program under test
+	Env     []string   `json:"env"`     // added process environment
+	Cases   []caseSpec `json:"cases"`   // test cases
+}
+
+// caseSpec represents a test case of a case file. The argument list does not
+// include the program name.
+type caseSpec struct {
+	Name          string                 `json:"name"`
+	Args          []string               `json:"args"`
+	Stdin         string                 `json:"stdin"`
+	Stdout        string                 `json:"stdout"`
+	Stderr        string                 `json:"stderr"`
+	Err           string                 `json:"err"`
+	Exit          exitSpec               `json:"exit"`
+	File          string                 `json:"file"`
+	JSON          map[string]string      `json:"json"`
+	Logs          []string               `json:"logs"`
+	LogSubset     bool                   `json:"logSubset"`
+	Vars          map[string]interface{} `json:"vars"`
+	IgnoreColumns []string               `json:"ignoreColumns"`
+	KeyColumn     string                 `json:"keyColumn"`
+	AbsTolerance  float64                `json:"absTolerance"`
+	RelTolerance  float64                `json:"relTolerance"`
+	Unordered     bool                   `json:"unordered"`
+	MaxDuration   duration               `json:"maxDuration"`
+	MaxRSS        int64                  `json:"maxRSS"`
+	MaxCPU        duration               `json:"maxCPU"`
+}
+
+// exitSpec represents a smart exit code expectation, written as a number or a
+// string.
+type exitSpec string
+
+func (e *exitSpec) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*e = exitSpec(strconv.Itoa(n))
+		return nil
+	}
+	return json.Unmarshal(data, (*string)(e))
+}
+
+// duration represents a duration written as a string, such as "1.5s".
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	*d = duration(v)
+	return err
+}
+
+// loadSuite loads the case file with the .yaml, .yml, .json or .txtar
+// extension.
+func loadSuite(file string) (*suite, error) {
+	name, err := suiteName(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(file)
+	s := &suite{Name: name}
+
+	switch ext {
+
+	case ".yaml", ".yml":
+		err = decodeYAML(string(data), s)
+
+	case ".json":
+		err = decodeJSON(data, s)
+
+	case ".txtar":
+		err = decodeTxtar(string(data), s)
+
+	default:
+		return nil, fmt.Errorf("%s: unsupported case file extension: %q", file, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	if s.Program == "" {
+		return nil, fmt.Errorf("%s: missing program", file)
+	}
+	return s, nil
+}
+
+// suiteName returns the name of the suite of the case file: its clean slash
+// separated path relative to the current directory, without the extension,
+// such as "api/hello" for the file ./api/hello.yaml. Case files outside the
+// current directory are rejected, since their names could collide with the
+// names of other suites.
+func suiteName(file string) (string, error) {
+	name := strings.TrimSuffix(file, filepath.Ext(file))
+	if filepath.IsAbs(name) {
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		if name, err = filepath.Rel(dir, name); err != nil {
+			return "", err
+		}
+	}
+
+	name = filepath.ToSlash(filepath.Clean(name))
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", errors.New("case file outside the current directory")
+	}
+	return name, nil
+}
+
+// goldenPrefix returns the prefix of the gold master names of the suite.
+func (s *suite) goldenPrefix() string {
+	return strings.Replace(s.Name, "/", "-", -1) + "-"
+}
+
+// goldenNames returns the gold master names of the cases of the suite, without
+// extension, as written by the golden package.
+func (s *suite) goldenNames() []string {
+	names := make([]string, len(s.Cases))
+	for i, c := range s.Cases {
+		names[i] = s.goldenPrefix() + strings.Replace(caseName(i, c.Name), "/", "-", -1)
+	}
+	return names
+}
+
+// decodeJSON decodes the JSON document into v. Unknown fields are rejected.
+func decodeJSON(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	return d.Decode(v)
+}
+
+// decodeYAML decodes the YAML document into v, as its JSON equivalent.
+func decodeYAML(data string, v interface{}) error {
+	doc, err := yaml.Parse(data)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(b, v)
+}
+
+// decodeTxtar decodes the txtar archive into the suite. The comment is a YAML
+// document holding the suite fields, and each file named "case/field" holds a
+// field of the named case, in order of appearance. The args field lists an
+// argument per line, the exit field is trimmed, and the other fields are used
+// as is.
+func decodeTxtar(data string, s *suite) error {
+	a := txtar.Parse(data)
+	if err := decodeYAML(a.Comment, s); err != nil {
+		return err
+	}
+
+	index := map[string]int{}
+	for i, c := range s.Cases {
+		index[c.Name] = i
+	}
+
+	for _, f := range a.Files {
+		i := strings.LastIndex(f.Name, "/")
+		if i < 0 {
+			return fmt.Errorf("invalid file name: %q, want case/field", f.Name)
+		}
+		name, field := f.Name[:i], f.Name[i+1:]
+
+		n, ok := index[name]
+		if !ok {
+			n = len(s.Cases)
+			index[name] = n
+			s.Cases = append(s.Cases, caseSpec{Name: name})
+		}
+		c := &s.Cases[n]
+
+		switch field {
+		case "args":
+			if args := strings.TrimSuffix(f.Data, "\n"); args != "" {
+				c.Args = strings.Split(args, "\n")
+			}
+		case "stdin":
+			c.Stdin = f.Data
+		case "stdout":
+			c.Stdout = f.Data
+		case "stderr":
+			c.Stderr = f.Data
+		case "err":
+			c.Err = strings.TrimSuffix(f.Data, "\n")
+		case "exit":
+			c.Exit = exitSpec(strings.TrimSpace(f.Data))
+		default:
+			return fmt.Errorf("unknown field: %q", f.Name)
+		}
+	}
+	return nil
+}
+
+// anyExitError holds the default expected error, which matches the error of
+// any exit code.
+const anyExitError = `^(exit status \d+)?$`
+
+// cases returns the test cases of the suite.
+func (s *suite) cases() []golden.Case {
+	cases := make([]golden.Case, len(s.Cases))
+	for i, c := range s.Cases {
+		if c.Err == "" {
+			c.Err = anyExitError
+		}
+		cases[i] = golden.Case{
+			Name:          c.Name,
+			Args:          append([]string{s.Program}, c.Args...),
+			Stdin:         c.Stdin,
+			WantFile:      c.File,
+			IgnoreColumns: c.IgnoreColumns,
+			KeyColumn:     c.KeyColumn,
+			AbsTolerance:  c.AbsTolerance,
+			RelTolerance:  c.RelTolerance,
+			Unordered:     c.Unordered,
+			Vars:          c.Vars,
+			WantStdout:    c.Stdout,
+			WantStderr:    c.Stderr,
+			WantJSON:      c.JSON,
+			WantLogs:      c.Logs,
+			LogSubset:     c.LogSubset,
+			WantErr:       c.Err,
+			WantExit:      string(c.Exit),
+			MaxDuration:   time.Duration(c.MaxDuration),
+			MaxRSS:        c.MaxRSS,
+			MaxCPU:        time.Duration(c.MaxCPU),
+		}
+	}
+	return cases
+}
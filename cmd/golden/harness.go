@@ -0,0 +1,151 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// result represents the result of a test case.
+type result struct {
+	suite    string        // suite name
+	name     string        // full case name, such as "suite/case"
+	failed   bool          // failed case
+	skipped  bool          // skipped case
+	messages []string      // error and log messages
+	duration time.Duration // run duration
+}
+
+// harness implements the testing.TB interface required by the golden package,
+// running each subtest in its own goroutine and collecting its result. The
+// embedded interface is nil: the other methods are not supported.
+type harness struct {
+	testing.TB
+
+	name    string    // test name
+	results *[]result // results of the subtests
+
+	mutex    sync.Mutex
+	failed   bool
+	skipped  bool
+	messages []string
+}
+
+// newHarness returns the harness of the named suite.
+func newHarness(name string) *harness {
+	return &harness{name: name, results: new([]result)}
+}
+
+// Run runs f as a subtest with the specified name and reports whether f
+// succeeded. An empty name is replaced by the subtest number, as by the
+// testing package.
+func (h *harness) Run(name string, f func(t testing.TB)) bool {
+	sub := &harness{
+		name:    h.name + "/" + caseName(len(*h.results), name),
+		results: h.results,
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(sub)
+	}()
+	<-done
+
+	*h.results = append(*h.results, result{
+		suite:    h.name,
+		name:     sub.name,
+		failed:   sub.Failed(),
+		skipped:  sub.Skipped(),
+		messages: sub.messages,
+		duration: time.Since(start),
+	})
+	if sub.Failed() {
+		h.Fail()
+	}
+	return !sub.Failed()
+}
+
+func (h *harness) Name() string { return h.name }
+func (h *harness) Helper()      {}
+
+func (h *harness) Log(args ...interface{}) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.messages = append(h.messages, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (h *harness) Logf(format string, args ...interface{}) {
+	h.Log(fmt.Sprintf(format, args...))
+}
+
+func (h *harness) Fail() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.failed = true
+}
+
+func (h *harness) Failed() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.failed
+}
+
+// FailNow marks the test as failed and stops its goroutine.
+func (h *harness) FailNow() {
+	h.Fail()
+	runtime.Goexit()
+}
+
+func (h *harness) Error(args ...interface{}) {
+	h.Log(args...)
+	h.Fail()
+}
+
+func (h *harness) Errorf(format string, args ...interface{}) {
+	h.Logf(format, args...)
+	h.Fail()
+}
+
+func (h *harness) Fatal(args ...interface{}) {
+	h.Log(args...)
+	h.FailNow()
+}
+
+func (h *harness) Fatalf(format string, args ...interface{}) {
+	h.Logf(format, args...)
+	h.FailNow()
+}
+
+// SkipNow marks the test as skipped and stops its goroutine.
+func (h *harness) SkipNow() {
+	h.mutex.Lock()
+	h.skipped = true
+	h.mutex.Unlock()
+	runtime.Goexit()
+}
+
+func (h *harness) Skip(args ...interface{}) {
+	h.Log(args...)
+	h.SkipNow()
+}
+
+func (h *harness) Skipf(format string, args ...interface{}) {
+	h.Logf(format, args...)
+	h.SkipNow()
+}
+
+func (h *harness) Skipped() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.skipped
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+/*
+Golden is a tool for managing gold master files and running case files.
+
+Usage:
+
+	golden <command> [arguments]
+
+The commands are:
+
+	run       run the test cases of case files against their programs
+	update    run the test cases of case files and update their gold masters
+	prune     remove the gold masters of case files not belonging to any case
+	review    review the pending actual outputs of failed gold master matches
+
+Run "golden help <command>" for more information about a command.
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command represents a golden command.
+type command struct {
+	name  string                                 // command name
+	usage string                                 // usage message
+	run   func(args []string, stdio stdio) error // run function
+}
+
+// stdio represents the standard input and outputs of a command.
+type stdio struct {
+	in  io.Reader
+	out io.Writer
+	err io.Writer
+}
+
+// commands holds the list of the available commands.
+var commands = []*command{
+	runCommand,
+	updateCommand,
+	pruneCommand,
+	reviewCommand,
+}
+
+// usage holds the main usage message.
+const usage = `Golden is a tool for managing gold master files and running case files.
+
+Usage:
+
+    golden <command> [arguments]
+
+The commands are:
+
+    run       run the test cases of case files against their programs
+    update    run the test cases of case files and update their gold masters
+    prune     remove the gold masters of case files not belonging to any case
+    review    review the pending actual outputs of failed gold master matches
+
+Run "golden help <command>" for more information about a command.
+`
+
+// errUsage is returned by a command run with invalid arguments.
+var errUsage = fmt.Errorf("invalid arguments")
+
+func main() {
+	os.Exit(run(os.Args[1:], stdio{os.Stdin, os.Stdout, os.Stderr}))
+}
+
+// run runs the command named by the first argument and returns the exit code.
+func run(args []string, stdio stdio) int {
+	if len(args) == 0 {
+		fmt.Fprint(stdio.err, usage)
+		return 2
+	}
+
+	if args[0] == "help" {
+		if len(args) == 1 {
+			fmt.Fprint(stdio.out, usage)
+			return 0
+		}
+		if c := find(args[1]); c != nil {
+			fmt.Fprint(stdio.out, c.usage)
+			return 0
+		}
+		fmt.Fprintf(stdio.err, "golden: unknown help topic %q\n", args[1])
+		return 2
+	}
+
+	c := find(args[0])
+	if c == nil {
+		fmt.Fprintf(stdio.err, "golden: unknown command %q\n", args[0])
+		fmt.Fprint(stdio.err, usage)
+		return 2
+	}
+
+	switch err := c.run(args[1:], stdio); err {
+
+	case nil:
+		return 0
+
+	case errUsage:
+		fmt.Fprint(stdio.err, c.usage)
+		return 2
+
+	default:
+		fmt.Fprintf(stdio.err, "golden %s: %v\n", c.name, err)
+		return 1
+	}
+}
+
+// find returns the named command, or nil if not found.
+func find(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writers holds the result writers by format name.
+var writers = map[string]func(w io.Writer, results []result) error{
+	"text":  writeText,
+	"tap":   writeTAP,
+	"junit": writeJUnit,
+}
+
+// indent returns the lines of the string s with the prefix added.
+func indent(s, prefix string) string {
+	return prefix + strings.Replace(strings.TrimSuffix(s, "\n"), "\n", "\n"+prefix, -1) + "\n"
+}
+
+// writeText writes a line for each result, followed by its messages, and a
+// summary line.
+func writeText(w io.Writer, results []result) error {
+	var b strings.Builder
+	failed := 0
+	for _, r := range results {
+		status := "ok  "
+		switch {
+		case r.failed:
+			status = "FAIL"
+			failed++
+		case r.skipped:
+			status = "SKIP"
+		}
+		fmt.Fprintf(&b, "%s %s (%.2fs)\n", status, r.name, r.duration.Seconds())
+		for _, m := range r.messages {
+			b.WriteString(indent(m, "    "))
+		}
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed\n", len(results)-failed, failed)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeTAP writes the results in the TAP version 13 format. The messages of
+// each result are written as a YAML diagnostic block.
+func writeTAP(w io.Writer, results []result) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(results))
+	for i, r := range results {
+		status := "ok"
+		if r.failed {
+			status = "not ok"
+		}
+		fmt.Fprintf(&b, "%s %d - %s", status, i+1, r.name)
+		if r.skipped {
+			b.WriteString(" # SKIP")
+		}
+		b.WriteString("\n")
+
+		if len(r.messages) > 0 {
+			b.WriteString("  ---\n")
+			b.WriteString("  message: |\n")
+			b.WriteString(indent(strings.Join(r.messages, "\n"), "    "))
+			b.WriteString("  ...\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// junitSuites represents the root element of a JUnit XML report.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite represents a test suite of a JUnit XML report.
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+// junitCase represents a test case of a JUnit XML report.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+// junitMessage represents a failure or skip message of a JUnit XML report.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// writeJUnit writes the results in the JUnit XML format, with a test suite for
+// each case file.
+func writeJUnit(w io.Writer, results []result) error {
+	report := junitSuites{}
+	index := map[string]int{}
+	var times []float64
+
+	for _, r := range results {
+		suite, name := r.suite, strings.TrimPrefix(r.name, r.suite+"/")
+
+		n, ok := index[suite]
+		if !ok {
+			n = len(report.Suites)
+			index[suite] = n
+			report.Suites = append(report.Suites, junitSuite{Name: suite})
+			times = append(times, 0)
+		}
+		s := &report.Suites[n]
+
+		c := junitCase{Name: name, ClassName: suite, Time: fmt.Sprintf("%.3f", r.duration.Seconds())}
+		text := strings.Join(r.messages, "\n")
+		message := strings.SplitN(text, "\n", 2)[0]
+		switch {
+		case r.failed:
+			c.Failure = &junitMessage{Message: message, Text: text}
+			s.Failures++
+			report.Failures++
+		case r.skipped:
+			c.Skipped = &junitMessage{Message: message, Text: text}
+			s.Skipped++
+			report.Skipped++
+		}
+
+		s.Cases = append(s.Cases, c)
+		s.Tests++
+		report.Tests++
+		times[n] += r.duration.Seconds()
+	}
+	for i := range report.Suites {
+		report.Suites[i].Time = fmt.Sprintf("%.3f", times[i])
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+string(data)+"\n")
+	return err
+}
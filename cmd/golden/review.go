@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/larhun/golden/internal/diff"
+)
+
+// reviewCommand implements the review command.
+var reviewCommand = &command{
+	name: "review",
+	usage: `Usage: golden review [dir...]
+
+Review lists the pending actual outputs written next to the gold masters by the
+failed matches of a test run with the golden-actual flag, or with a non-empty
+GOLDEN_ACTUAL environment variable (files with the ".actual" extension), found
+in the named directories, testdata/golden by default, and all their
+subdirectories.
+For each pending file it shows the differences from the gold master and asks
+to:
+
+    a    accept the actual output, replacing the gold master
+    r    reject the actual output, removing the pending file
+    s    skip to the next pending file
+    q    quit the review
+`,
+	run: review,
+}
+
+// review runs the review command.
+func review(args []string, stdio stdio) error {
+	if len(args) == 0 {
+		args = []string{filepath.Join("testdata", "golden")}
+	}
+
+	var pending []string
+	for _, dir := range args {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode().IsRegular() && strings.HasSuffix(path, ".actual") {
+				pending = append(pending, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	sort.Strings(pending)
+
+	if len(pending) == 0 {
+		fmt.Fprintln(stdio.out, "no pending actual outputs")
+		return nil
+	}
+
+	in := bufio.NewScanner(stdio.in)
+	for i, actual := range pending {
+		file := strings.TrimSuffix(actual, ".actual")
+
+		got, err := ioutil.ReadFile(actual)
+		if err != nil {
+			return err
+		}
+
+		want, err := ioutil.ReadFile(file)
+		switch {
+
+		case os.IsNotExist(err):
+			fmt.Fprintf(stdio.out, "[%d/%d] %s (new)\n", i+1, len(pending), file)
+			fmt.Fprint(stdio.out, diff.Unified("/dev/null", actual, "", string(got)))
+
+		case err != nil:
+			return err
+
+		default:
+			fmt.Fprintf(stdio.out, "[%d/%d] %s\n", i+1, len(pending), file)
+			if d := diff.Unified(file, actual, string(want), string(got)); d != "" {
+				fmt.Fprint(stdio.out, d)
+			} else {
+				fmt.Fprintln(stdio.out, "no line differences")
+			}
+		}
+
+		answer, err := ask(in, stdio)
+		if err != nil || answer == "q" {
+			return err
+		}
+
+		switch answer {
+
+		case "a":
+			if err := os.Rename(actual, file); err != nil {
+				return err
+			}
+			fmt.Fprintln(stdio.out, "accepted")
+
+		case "r":
+			if err := os.Remove(actual); err != nil {
+				return err
+			}
+			fmt.Fprintln(stdio.out, "rejected")
+
+		case "s":
+			fmt.Fprintln(stdio.out, "skipped")
+		}
+	}
+
+	return nil
+}
+
+// ask asks for a review action until a valid answer is read and returns it. The
+// end of the input is answered by quitting.
+func ask(in *bufio.Scanner, stdio stdio) (string, error) {
+	for {
+		fmt.Fprint(stdio.out, "accept, reject, skip or quit [a,r,s,q]? ")
+		if !in.Scan() {
+			fmt.Fprintln(stdio.out)
+			return "q", in.Err()
+		}
+
+		switch answer := strings.TrimSpace(in.Text()); answer {
+		case "a", "r", "s", "q":
+			return answer, nil
+		}
+	}
+}
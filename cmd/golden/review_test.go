@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReview(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a":        "old\n",
+		"a.actual": "new\n",
+		"b.actual": "new\n",
+		"c":        "old\n",
+		"c.actual": "new\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	in := strings.NewReader("a\nr\nx\ns\n")
+	if code := run([]string{"review", dir}, stdio{in, out, out}); code != 0 {
+		t.Fatalf("review error: exit code %d:\n%s", code, out)
+	}
+
+	for _, want := range []string{
+		"[1/3] " + filepath.Join(dir, "a") + "\n",
+		"-old\n+new\n",
+		"[2/3] " + filepath.Join(dir, "b") + " (new)\n",
+		"accepted\n", "rejected\n", "skipped\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("review error: missing %q in output:\n%s", want, out)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"a": "new\n", "a.actual": "", "b": "", "b.actual": "", "c": "old\n", "c.actual": "new\n",
+	} {
+		got, _ := ioutil.ReadFile(filepath.Join(dir, name))
+		if string(got) != want {
+			t.Errorf("review error: file %s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		code int
+		want string
+	}{
+		{nil, 2, "Usage:"},
+		{[]string{"help"}, 0, "Usage:"},
+		{[]string{"help", "review"}, 0, "Usage: golden review"},
+		{[]string{"help", "unknown"}, 2, "unknown help topic"},
+		{[]string{"unknown"}, 2, "unknown command"},
+	} {
+		out := &bytes.Buffer{}
+		if code := run(tc.args, stdio{nil, out, out}); code != tc.code || !strings.Contains(out.String(), tc.want) {
+			t.Errorf("run(%q): got %d, %q, want %d, %q", tc.args, code, out, tc.code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/larhun/golden"
+)
+
+// casesUsage holds the description of the case files shared by the run and
+// update commands.
+const casesUsage = `
+A case file holds the program under test, its added environment and the list of
+test cases. YAML (.yaml or .yml) and JSON (.json) case files look like:
+
+    program: ./hello
+    env: [LANG=C]
+    cases:
+      - name: output
+        args: [World]
+        stdout: Hello World!
+      - name: usage
+        stderr: "usage: hello..."
+        exit: 2
+
+The fields of a case are name, args (without the program name), stdin, stdout,
+stderr, err, exit, file, json, logs, logSubset, vars, ignoreColumns, keyColumn,
+absTolerance, relTolerance, unordered, maxDuration, maxRSS and maxCPU, which
+hold the values of the Case fields of the golden package, with the same smart
+validation strings. The err field matches the error of any exit code by default.
+
+Txtar (.txtar) case files hold the program and the env fields as a YAML
+comment, and a file named "case/field" for each multi-line field of a case: the
+args (one per line), stdin, stdout, stderr, err and exit fields:
+
+    program: ./hello
+    -- output/args --
+    World
+    -- output/stdout --
+    Hello World!
+
+Gold masters are stored in testdata/golden, in the current directory, with name
+derived from the case file path and the case names, such as hello-output.json,
+or api-hello-output.json for the api/hello.yaml case file. Case files outside
+the current directory are rejected.
+`
+
+// runCommand implements the run command.
+var runCommand = &command{
+	name: "run",
+	usage: `Usage: golden run [-format text|tap|junit] file...
+
+Run runs the test cases of the named case files against their programs and
+prints the results in the specified format: text (the default), TAP version 13
+or JUnit XML. The exit code is 1 if any case fails.
+` + casesUsage,
+	run: func(args []string, stdio stdio) error {
+		return runCases("run", args, stdio)
+	},
+}
+
+// updateCommand implements the update command.
+var updateCommand = &command{
+	name: "update",
+	usage: `Usage: golden update [-format text|tap|junit] file...
+
+Update runs the test cases of the named case files as by the run command, with
+the update flag enabled: the gold masters are written with the actual outputs.
+See "golden help run" for the format of the case files.
+`,
+	run: func(args []string, stdio stdio) error {
+		if err := flag.Set("update", "true"); err != nil {
+			return err
+		}
+		defer flag.Set("update", "false")
+		return runCases("update", args, stdio)
+	},
+}
+
+// pruneCommand implements the prune command.
+var pruneCommand = &command{
+	name: "prune",
+	usage: `Usage: golden prune [-n] file...
+
+Prune removes the gold masters of the named case files, and their pending
+files, that do not belong to any of their cases, such as those of removed or
+renamed cases. The gold masters belonging to the case files found in the
+current directory tree are kept, such as those of a suite whose name extends
+the name of a named one. The -n flag lists the files without removing them.
+`,
+	run: prune,
+}
+
+// runCases runs the named command, run or update.
+func runCases(name string, args []string, stdio stdio) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	format := fs.String("format", "text", "")
+	if fs.Parse(args) != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+
+	write, ok := writers[*format]
+	if !ok {
+		return errUsage
+	}
+
+	var results []result
+	for _, file := range fs.Args() {
+		s, err := loadSuite(file)
+		if err != nil {
+			return err
+		}
+
+		h := newHarness(s.Name)
+		golden.Test(h, golden.Program(s.Program, s.Env), s.cases())
+		results = append(results, *h.results...)
+	}
+
+	if err := write(stdio.out, results); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.failed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cases failed", failed, len(results))
+	}
+	return nil
+}
+
+// caseName returns the name of the numbered case as reported by the harness.
+func caseName(i int, name string) string {
+	if name == "" {
+		return fmt.Sprintf("#%02d", i)
+	}
+	return strings.Replace(name, " ", "_", -1)
+}
+
+// prune runs the prune command.
+func prune(args []string, stdio stdio) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	dryRun := fs.Bool("n", false, "")
+	if fs.Parse(args) != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+
+	dir := filepath.Join("testdata", "golden")
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	suites := map[string]bool{} // known suites by gold master prefix, true if named
+	var names []string          // gold master names of all the known cases
+	for _, file := range fs.Args() {
+		s, err := loadSuite(file)
+		if err != nil {
+			return err
+		}
+		suites[s.goldenPrefix()] = true
+		names = append(names, s.goldenNames()...)
+	}
+
+	for _, s := range findSuites(dir) {
+		if _, ok := suites[s.goldenPrefix()]; !ok {
+			suites[s.goldenPrefix()] = false
+		}
+		names = append(names, s.goldenNames()...)
+	}
+
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || !suites[owner(entry.Name(), suites)] || belongs(entry.Name(), names) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if *dryRun {
+			fmt.Fprintln(stdio.out, "would remove", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdio.out, "removed", path)
+	}
+	return nil
+}
+
+// findSuites returns the suites of the case files found in the current
+// directory tree, except in hidden directories and in the gold master
+// directory. Files that are not valid case files are ignored.
+func findSuites(goldenDir string) []*suite {
+	var suites []*suite
+	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		switch {
+		case err != nil:
+			return nil
+		case info.IsDir() && (path == goldenDir || path != "." && strings.HasPrefix(info.Name(), ".")):
+			return filepath.SkipDir
+		case info.IsDir():
+			return nil
+		}
+
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json", ".txtar":
+			if s, err := loadSuite(path); err == nil {
+				suites = append(suites, s)
+			}
+		}
+		return nil
+	})
+	return suites
+}
+
+// owner returns the longest gold master prefix of the suites that the file
+// name starts with, or an empty string if none.
+func owner(file string, suites map[string]bool) string {
+	longest := ""
+	for prefix := range suites {
+		if strings.HasPrefix(file, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+	return longest
+}
+
+// belongs reports whether the gold master file belongs to one of the named
+// cases: its name is equal to a case name, optionally followed by extensions.
+func belongs(file string, names []string) bool {
+	for _, name := range names {
+		if file == name || strings.HasPrefix(file, name+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// caseFiles holds the case files used by the tests.
+var caseFiles = map[string]string{
+	"ok.yaml": `program: echo
+cases:
+  - name: literal
+    args: [hello]
+    stdout: "hello\n"
+  - name: golden
+    args: [hello, golden]
+    stdout: golden
+  - args: [-n, "3.14159"]
+    stdout: "3.14"
+    absTolerance: 0.01
+`,
+	"fail.json": `{
+  "program": "echo",
+  "cases": [
+    {"name": "pass", "args": ["a"], "stdout": "a\n"},
+    {"name": "fail", "args": ["b"], "stdout": "a\n"}
+  ]
+}`,
+	"script.txtar": `program: echo
+env: [LANG=C]
+-- multi/args --
+one
+two
+-- multi/stdout --
+one two
+-- empty/stdout --
+
+-- empty/exit --
+0
+`,
+	"ok-world.yaml": `program: echo
+cases:
+  - name: golden
+    args: [world]
+    stdout: golden
+`,
+	"sub/ok.yaml": `program: echo
+cases:
+  - name: literal
+    args: [sub]
+    stdout: "sub\n"
+`,
+	"bad.yaml": `program: echo
+cases:
+  - name: typo
+    stdot: a
+`,
+}
+
+// chdir creates a temporary directory holding the case files, changes to it
+// and returns a function restoring the working directory and removing it.
+func chdir(t *testing.T) func() {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo program not found")
+	}
+
+	dir, err := ioutil.TempDir("", "go-golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range caseFiles {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRun(t *testing.T) {
+	defer chdir(t)()
+
+	for _, tc := range []struct {
+		args []string
+		code int
+		want []string
+	}{
+		{
+			[]string{"run", "ok.yaml"}, 1,
+			[]string{"ok   ok/literal", "FAIL ok/golden", "read error", "ok   ok/#02", "2 passed, 1 failed\n"},
+		}, {
+			[]string{"update", "ok.yaml"}, 0,
+			[]string{"ok   ok/golden", "3 passed, 0 failed\n"},
+		}, {
+			[]string{"run", "-format", "tap", "ok.yaml", "script.txtar"}, 0,
+			[]string{"TAP version 13\n1..5\n", "ok 2 - ok/golden\n", "ok 4 - script/multi\n", "ok 5 - script/empty\n"},
+		}, {
+			[]string{"run", "-format", "junit", "fail.json"}, 1,
+			[]string{`<testsuite name="fail" tests="2" failures="1"`, `<testcase name="fail" classname="fail"`, `<failure message="WantStdout match error:"><![CDATA[WantStdout match error:`, "1 of 2 cases failed"},
+		}, {
+			[]string{"run", "-format", "junit", "sub/ok.yaml"}, 0,
+			[]string{`<testsuite name="sub/ok" tests="1" failures="0"`, `<testcase name="literal" classname="sub/ok"`},
+		}, {
+			[]string{"run", "bad.yaml"}, 1,
+			[]string{`bad.yaml: json: unknown field "stdot"`},
+		}, {
+			[]string{"prune", "../ok.yaml"}, 1,
+			[]string{"../ok.yaml: case file outside the current directory"},
+		}, {
+			[]string{"run", "-format", "xml", "ok.yaml"}, 2,
+			[]string{"Usage: golden run"},
+		},
+	} {
+		out := &bytes.Buffer{}
+		code := run(tc.args, stdio{nil, out, out})
+		if code != tc.code {
+			t.Errorf("run(%q): got exit code %d, want %d:\n%s", tc.args, code, tc.code, out)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("run(%q): missing %q in output:\n%s", tc.args, want, out)
+			}
+		}
+	}
+
+	got, _ := ioutil.ReadFile(filepath.Join("testdata", "golden", "ok-golden"))
+	if string(got) != "hello golden\n" {
+		t.Errorf("update error: got gold master %q, want %q", got, "hello golden\n")
+	}
+}
+
+func TestSuiteName(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		file, want string
+	}{
+		{"hello.yaml", "hello"},
+		{"./api/hello.yaml", "api/hello"},
+		{"api/../hello.json", "hello"},
+		{".hidden/a.yaml", ".hidden/a"},
+		{"..hello.yaml", "..hello"},
+		{filepath.Join(dir, "api", "hello.yaml"), "api/hello"},
+		{"../hello.yaml", "error"},
+		{"api/../../hello.yaml", "error"},
+		{"../../hello.yaml", "error"},
+	} {
+		got, err := suiteName(tc.file)
+		if err != nil {
+			got = "error"
+		}
+		if got != tc.want {
+			t.Errorf("suiteName(%q): got %q, want %q", tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	defer chdir(t)()
+
+	dir := filepath.Join("testdata", "golden")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]bool{ // removed files
+		"ok-golden":        false,
+		"ok-golden.actual": false,
+		"ok-#02.txt":       false,
+		"ok-removed":       true,
+		"ok-removed.json":  true,
+		"ok-world-golden":  false, // owned by ok-world.yaml
+		"ok-world-removed": false, // stale file of ok-world.yaml
+		"sub-ok-removed":   false, // stale file of sub/ok.yaml
+		"fail-other":       false,
+	}
+	for name := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if code := run([]string{"prune", "-n", "ok.yaml"}, stdio{nil, out, out}); code != 0 {
+		t.Fatalf("prune error: exit code %d:\n%s", code, out)
+	}
+	if got, want := out.String(), "would remove "+filepath.Join(dir, "ok-removed")+"\n"+
+		"would remove "+filepath.Join(dir, "ok-removed.json")+"\n"; got != want {
+		t.Errorf("prune error: got %q, want %q", got, want)
+	}
+
+	out.Reset()
+	if code := run([]string{"prune", "ok.yaml"}, stdio{nil, out, out}); code != 0 {
+		t.Fatalf("prune error: exit code %d:\n%s", code, out)
+	}
+	for name, removed := range files {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if os.IsNotExist(err) != removed {
+			t.Errorf("prune error: file %s: removed %v, want %v", name, !removed, removed)
+		}
+	}
+
+	out.Reset()
+	if code := run([]string{"prune", "ok-world.yaml", "sub/ok.yaml"}, stdio{nil, out, out}); code != 0 {
+		t.Fatalf("prune error: exit code %d:\n%s", code, out)
+	}
+	if got, want := out.String(), "removed "+filepath.Join(dir, "ok-world-removed")+"\n"+
+		"removed "+filepath.Join(dir, "sub-ok-removed")+"\n"; got != want {
+		t.Errorf("prune error: got %q, want %q", got, want)
+	}
+}
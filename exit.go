@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// matchExit reports whether the exit code matches the want smart exit code
+// expectation. It returns an error if want has an invalid syntax.
+func matchExit(got int, want string) (bool, error) {
+	for _, term := range strings.Split(want, "|") {
+		switch {
+
+		case term == "any":
+			return true, nil
+
+		case term == "nonzero":
+			if got != 0 {
+				return true, nil
+			}
+
+		case strings.Contains(term, ".."):
+			i := strings.Index(term, "..")
+			min, err1 := strconv.Atoi(term[:i])
+			max, err2 := strconv.Atoi(term[i+2:])
+			if err1 != nil || err2 != nil || min > max {
+				return false, errors.New("invalid range: " + term)
+			}
+			if min <= got && got <= max {
+				return true, nil
+			}
+
+		default:
+			code, err := strconv.Atoi(term)
+			if err != nil {
+				return false, errors.New("invalid exit code: " + term)
+			}
+			if got == code {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// exit tests if the exit code of the command matches the smart expectation or,
+// if empty, the expected exit code. If not, accumulates an error. The name of
+// the terminating signal, if any, is reported along with the exit code.
+func (m *match) exit(command Runner, wantCode int, want string) {
+	name := "WantExit"
+	if want == "" {
+		name = "WantExitCode"
+		want = strconv.Itoa(wantCode)
+	}
+
+	got := command.ExitCode()
+	ok, err := matchExit(got, want)
+	if err != nil {
+		m.messages = append(m.messages, name+" syntax error:\n"+err.Error())
+		return
+	}
+
+	c := check{Name: name, Matcher: "exit", Got: strconv.Itoa(got), Want: want, Pass: ok}
+	if s := exitSignal(command); s != nil {
+		c.Got += " (signal: " + s.String() + ")"
+	}
+	if !ok {
+		c.Message = name + " match error:\ngot: " + c.Got + ", want: " + want
+	}
+	m.addCheck(c)
+}
+
+// exitSignal returns the signal that terminated the last run of the command, or
+// nil if the command does not implement the ExitSignaler interface.
+func exitSignal(command Runner) os.Signal {
+	if s, ok := command.(ExitSignaler); ok {
+		return s.ExitSignal()
+	}
+	return nil
+}
+
+// signalName returns the name of the signal that terminated the last run of the
+// command, or an empty string if none.
+func signalName(command Runner) string {
+	if s := exitSignal(command); s != nil {
+		return s.String()
+	}
+	return ""
+}
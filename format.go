@@ -22,8 +22,13 @@ func isMultiline(s string) bool {
 }
 
 // format returns a formatted error message that reports the test name and the
-// got and want values.
+// got and want values. Binary values are reported by their size, digest and
+// hex dump.
 func format(name, got, want string) string {
+	if !strings.HasPrefix(name, "WantFail") && (isBinary(got) || isBinary(want)) {
+		return formatBinary(name, got, want)
+	}
+
 	m := new(message)
 
 	m.WriteString(name)
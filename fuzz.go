@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package golden
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// argSeparator separates the arguments of a fuzzed argument list.
+const argSeparator = "\x00"
+
+// Result represents the inputs and outputs of a command run.
+type Result struct {
+	Args     []string // argument list
+	Stdin    string   // standard input
+	Stdout   string   // standard output
+	Stderr   string   // error output
+	Panic    string   // panic message
+	Err      string   // error message
+	ExitCode int      // exit code
+}
+
+// Property represents an invariant of the command runs. It returns an error
+// describing the violation, or nil if the run result satisfies the invariant.
+type Property func(r Result) error
+
+// NeverPanics is a property satisfied by the runs that do not panic.
+func NeverPanics(r Result) error {
+	if r.Panic != "" {
+		return errors.New("unexpected panic: " + r.Panic)
+	}
+	return nil
+}
+
+// QuietSuccess is a property satisfied by the runs that exit with a non-zero
+// code or with an empty error output.
+func QuietSuccess(r Result) error {
+	if r.ExitCode == 0 && r.Stderr != "" {
+		return fmt.Errorf("unexpected error output with exit code 0: %q", r.Stderr)
+	}
+	return nil
+}
+
+// ExitCodeIn returns a property satisfied by the runs that exit with one of the
+// listed codes.
+func ExitCodeIn(codes ...int) Property {
+	return func(r Result) error {
+		for _, code := range codes {
+			if r.ExitCode == code {
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected exit code: got %d, want one of %v", r.ExitCode, codes)
+	}
+}
+
+// All returns a property satisfied by the runs that satisfy all the listed
+// properties. The first violation is returned.
+func All(properties ...Property) Property {
+	return func(r Result) error {
+		for _, p := range properties {
+			if err := p(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Fuzz fuzzes the specified command with argument lists and standard inputs
+// derived from the listed cases, which seed the corpus, and tests if each run
+// satisfies the property. The command name, the first argument, is taken from
+// the first case and never mutated. The standard input is fuzzed only if the
+// command implements the StdinSetter interface.
+//
+//	func FuzzXxx(f *testing.F) {
+//	    Fuzz(f, command, cases, All(NeverPanics, ExitCodeIn(0, 1, 2), QuietSuccess))
+//	}
+func Fuzz(f *testing.F, command Runner, testCases []Case, property Property) {
+	f.Helper()
+
+	var name string
+	for _, tc := range testCases {
+		if len(tc.Args) == 0 {
+			continue
+		}
+		if name == "" {
+			name = tc.Args[0]
+		}
+		f.Add(strings.Join(tc.Args[1:], argSeparator), tc.Stdin)
+	}
+	if name == "" {
+		f.Fatal("Fuzz error:\nmissing command name")
+	}
+
+	_, hasStdin := command.(StdinSetter)
+
+	f.Fuzz(func(t *testing.T, args, stdin string) {
+		r := Result{Args: []string{name}}
+		if args != "" {
+			r.Args = append(r.Args, strings.Split(args, argSeparator)...)
+		}
+		if hasStdin {
+			r.Stdin = stdin
+		}
+
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+
+		m := newMatch(t, nil)
+		m.setStdin(command, r.Stdin)
+		command.SetStdout(stdout)
+		command.SetStderr(stderr)
+
+		r.Panic = m.run(func() {
+			if err := command.Run(r.Args); err != nil {
+				r.Err = err.Error()
+			}
+		})
+
+		r.Stdout, r.Stderr = stdout.String(), stderr.String()
+		r.ExitCode = command.ExitCode()
+
+		if err := property(r); err != nil {
+			m.messages = append(m.messages, fmt.Sprintf("Property error:\n%v\nargs: %q\nstdin: %q", err, r.Args, r.Stdin))
+		}
+		m.done()
+	})
+}
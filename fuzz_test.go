@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package golden_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/larhun/golden"
+)
+
+// pureEcho represents the echo command restricted to the subcommands without
+// side effects, so that fuzzing neither writes files nor panics.
+type pureEcho struct {
+	echo
+}
+
+func (e *pureEcho) Run(args []string) error {
+	if len(args) > 1 {
+		switch args[1] {
+		case "file", "archive", "image", "panic":
+			e.exitCode = 2
+			return errors.New("invalid subcommand: " + args[1])
+		}
+	}
+	return e.echo.Run(args)
+}
+
+func FuzzEcho(f *testing.F) {
+	Fuzz(f, new(pureEcho), []Case{
+		{Args: []string{"echo", "stdout", "value"}},
+		{Args: []string{"echo", "stderr", "error"}},
+		{Args: []string{"echo", "exit"}},
+	}, All(NeverPanics, ExitCodeIn(0, 1, 2, 3, 4), QuietSuccess))
+}
+
+func TestProperties(t *testing.T) {
+	property := All(NeverPanics, ExitCodeIn(0, 1), QuietSuccess)
+
+	for _, test := range []struct {
+		result Result
+		want   string
+	}{
+		{Result{Stdout: "value"}, ""},
+		{Result{Stderr: "error", ExitCode: 1}, ""},
+		{Result{Panic: "boom", ExitCode: 2}, "unexpected panic: boom"},
+		{Result{ExitCode: 2}, "unexpected exit code: got 2, want one of [0 1]"},
+		{Result{Stderr: "warning"}, `unexpected error output with exit code 0: "warning"`},
+	} {
+		var got string
+		if err := property(test.result); err != nil {
+			got = err.Error()
+		}
+		if got != test.want {
+			t.Errorf("property error: got %q, want %q", got, test.want)
+		}
+	}
+}
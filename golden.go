@@ -45,6 +45,53 @@ name derived from the test case name:
     "golden"         // match file testdata/golden/TestXxx-output
     "golden.json"    // match file testdata/golden/TestXxx-output.json
 
+Gold masters with the .yaml, .yml and .toml extensions are parsed and compared
+structurally, ignoring formatting, comments and key order. Each difference is
+reported with the path of the differing value, such as "servers[0].port". The
+update flag stores the canonical form of the actual output.
+
+Gold masters with the .xml and .html extensions are compared in the same way,
+ignoring attribute order, namespace prefixes, comments and insignificant
+whitespace. The first difference is reported with its XPath, such as
+"/root/item[2]/@id".
+
+Gold masters with the .tmpl extension are text templates, executed with the
+variables of the Vars field, that may hold placeholders for the environment
+specifics of the output. The any function matches any string and the regex
+function matches its regular expression:
+
+    version {{.Version}} in {{.TmpDir}}
+    built {{any}} with {{regex "\\d+"}} files
+
+The update flag keeps a template still matching the actual output, else replaces
+it with the actual output.
+
+Gold masters with the .csv and .tsv extensions are compared by rows and cells,
+with columns matched by name from the header line. Each difference is reported
+with its position, such as:
+
+    row 17, column "total": got 12.50, want 12.00
+
+The IgnoreColumns field lists the columns to be skipped, such as timestamps, and
+the KeyColumn field names the column matching the rows regardless of order.
+
+Gold masters with the .jsonl extension store the normalized records of a JSON
+Lines output, such as a structured log, one per line with sorted keys. The
+volatile time, ts and timestamp fields are ignored. Each difference is reported
+with the record position and the field path, such as "[2].msg". The WantLogs
+field asserts the records by field conditions instead:
+
+    Case{
+        Args:      []string{"fetch", "-timeout=1ms"},
+        WantLogs:  []string{`level=info msg~"^connecting"`, `level=error msg~timeout`},
+        LogSubset: true,
+    }
+
+Similarly, the WantJSON field asserts the values of queries applied to a JSON
+standard output, each one matched by its own smart validation string:
+
+    WantJSON: map[string]string{".status": "ok", ".items | length": "3", ".items": "golden.json"}
+
 A string representing a valid regular expression delimited by the "^" and "$"
 characters encodes a full pattern matching:
 
@@ -63,12 +110,117 @@ A string escaped by the equal symbol represents the substring after the symbol:
     "=...value" // match "...value"
     "=^value$"  // match "^value$"
 
+When the AbsTolerance or RelTolerance field is not zero, a non-empty literal
+string, or "golden" with an optional extension, for a gold master, of the
+WantStdout and WantStderr fields and of the WantJSON values encodes a match
+where the numbers are compared within the tolerances, and all the other
+characters are compared exactly:
+
+    "pi = 3.14159"   // match "pi = 3.141592653" with an AbsTolerance of 1e-5
+    "golden.txt"     // match file testdata/golden/TestXxx-output.txt
+
+When the Unordered field is true, a non-empty literal string, or "golden" with
+an optional extension, for a gold master, of the WantStdout and WantStderr
+fields and of the WantJSON values encodes a match where the lines are compared
+regardless of their order, as a multiset. The gold master is updated with the
+actual lines sorted:
+
+    "b\na"           // match "a\nb" or "b\na"
+    "golden.txt"     // match file testdata/golden/TestXxx-output.txt
+
 Any other string represents itself:
 
     "a...value"      // match "a...value"
     "^value|error"   // match "^value|error"
     "golden.file.go" // match "golden.file.go"
 
+The WantExit field holds a smart exit code expectation. It is a list of terms
+separated by the "|" character that matches if any term matches:
+
+    "any"         // match any exit code
+    "nonzero"     // match any exit code other than 0
+    "1|2"         // match 1 or 2
+    "1..125"      // match any exit code from 1 to 125
+    "0|128..255"  // match 0 or any exit code from 128 to 255
+
+If the command is terminated by a signal, the signal name is reported along
+with the exit code. The Signal field holds a signal sent to the command after a
+delay or after a line of the standard output is matched, such as:
+
+    Case{
+        Args:        []string{"server"},
+        Signal:      os.Interrupt,          // send SIGINT
+        SignalAfter: "...listening...",     // once this line is printed
+        WantStdout:  "...shutdown complete", // then test the cleanup output
+        WantExit:    "0",
+    }
+
+The WantSignal field holds the name of the expected terminating signal.
+
+Performance regressions are caught by the MaxDuration field, holding the
+maximum duration of a run, and by the MaxRSS and MaxCPU fields, holding the
+maximum memory and CPU time used by a program on Linux:
+
+    Case{
+        Args:        []string{"index", "testdata/large"},
+        MaxDuration: 2 * time.Second,
+        MaxRSS:      64 << 20, // 64 MiB
+    }
+
+Commands that change their output when connected to a terminal are tested by
+a Runner generated using the Terminal function, which runs the program in a
+pseudo-terminal with the specified window size (Linux only). The terminal output
+may be rendered through a virtual screen so that the final screen content is
+matched:
+
+    var command = Terminal("hello", nil, Term{Rows: 24, Cols: 80, Screen: true})
+
+Colored output and progress bars are normalized before matching by the
+functions listed in the Normalize field, such as StripANSI, SymbolizeANSI and
+Snapshot:
+
+    Case{
+        Args:       []string{"build"},
+        Normalize:  []Normalizer{SymbolizeANSI, Snapshot},
+        WantStdout: "<green>ok</green>\n", // for "\x1b[32mok\x1b[0m"
+    }
+
+Long-running interactive commands, such as a REPL, are tested by a Session that
+waits for an expected output, sends an input line and records a transcript that
+may be compared to a gold master:
+
+    s := NewSession(t, Program("repl", nil), []string{"repl"})
+    s.Expect("> $", time.Second) // wait for the prompt
+    s.Send("1+1")                // send a line
+    s.Expect("2\n> $", time.Second)
+    s.Close()                    // close the input and wait for exit
+    s.Match("golden")            // match file testdata/golden/TestXxx
+
+Commands calling HTTP services are tested by a Recorder, a local server that
+forwards the requests to the service and records the interactions to a gold
+master when running with the update flag, and replays them otherwise. The
+variable holding the recorder URL is added to the process environment of the
+command, and an unexpected request fails the test:
+
+    rec := NewRecorder(t, "https://api.example.com") // or "" for HTTP_PROXY
+    defer rec.Close()
+    Test(t, Program("client", []string{rec.Env("API_URL")}), cases)
+
+The Test function accepts a *testing.T, a *testing.B or a custom test harness
+implementing the SubtestRunner interface. The Benchmark function reports
+the time and the allocations per run of each case, after testing its outputs on
+the first run:
+
+    func BenchmarkXxx(b *testing.B) {
+        Benchmark(b, command, cases)
+    }
+
+With Go 1.18 or later, the argument lists and standard inputs of the cases seed
+the fuzzing of a command by the Fuzz function, which tests if each run satisfies
+a property, such as:
+
+    Fuzz(f, command, cases, All(NeverPanics, ExitCodeIn(0, 1, 2), QuietSuccess))
+
 All the gold masters used by TestXxx are updated by running the test with the
 update flag:
 
@@ -78,6 +230,50 @@ All the gold masters are updates by running:
 
     go test -update
 
+Running the tests with the golden-actual flag, or with a non-empty
+GOLDEN_ACTUAL environment variable, writes the actual output of each failed
+gold master match next to it to a pending file with the ".actual" extension,
+which is removed when matching. The pending files are reviewed, and accepted or
+rejected one by one, by the golden command:
+
+    GOLDEN_ACTUAL=1 go test
+    go run github.com/larhun/golden/cmd/golden review
+
+The golden command also runs test cases defined by YAML, JSON or txtar case
+files against any program, with the same smart validation strings and gold
+masters, and prints the results as text, TAP or JUnit XML:
+
+    golden run -format junit cases.yaml
+    golden update cases.yaml
+
+Running the tests with the golden-got flag, or with a non-empty GOLDEN_GOT
+environment variable, also writes the actual output of each failed literal
+match to a file with the ".got" extension, and the expected string to a file
+with the ".want" extension, to be compared by external diff tools. The actual
+outputs of failed gold master matches are written to the pending files, as by
+the golden-actual flag. Stale files are removed by successful matches:
+
+    GOLDEN_GOT=1 go test
+    diff testdata/golden/TestXxx-output.WantStdout.want testdata/golden/TestXxx-output.WantStdout.got
+
+A JSON report listing each test case with its inputs, actual outputs, performed
+checks, results and differences is written at the end of the run by running the
+tests with the golden-report flag, if the TestMain function of the package calls
+the Main function:
+
+    func TestMain(m *testing.M) {
+        Main(m)
+    }
+
+    go test -golden-report=report.json
+
+A self-contained HTML report, with side-by-side differences for each failed
+check and the commands that accept the new gold masters, is written to the
+report/index.html file at the end of the run by running the tests with the
+golden-html flag, if the TestMain function calls the Main function:
+
+    go test -golden-html=report
+
 See the testing files for usage examples.
 
 */
@@ -92,7 +288,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // goldenDir holds the directory of the gold master files.
@@ -111,18 +309,101 @@ type Case struct {
 	// Args holds the argument list that is passed to the command under test.
 	Args []string
 
+	// Stdin holds the standard input of the command under test. If not empty,
+	// the command must implement the StdinSetter interface.
+	Stdin string
+
 	// WantFile contains the name of the file that should be written by the
 	// command under test. If exists, the file is removed before running the
 	// test. The expected content is stored by a gold master file with the same
 	// extension. The TmpFiles helper function should be used to test files in a
 	// temporary directory.
+	//
+	// Archive files with the .zip, .tar, .tar.gz and .tgz extensions are
+	// compared by their regular and symbolic link entries, ignoring times and
+	// compression, and nested archives are compared recursively. The gold
+	// master lists the entries in the txtar format, with the .txtar extension
+	// added. The content of each listed entry is a smart validation string,
+	// while binary contents are listed by their size and SHA-256 digest.
+	//
+	// Image files with the .png, .jpg, .jpeg and .gif extensions are compared
+	// by their decoded pixels. The gold master is an image file with the same
+	// extension. On failure, an image highlighting the differing pixels is
+	// written next to the gold master, with the .diff.png extension added.
 	WantFile string
 
+	// FileModes, if true, includes the entry modes in the comparison of an
+	// archive file. See WantFile.
+	FileModes bool
+
+	// PixelTolerance holds the maximum difference of any 8-bit channel of two
+	// matching pixels, and MaxPixelRatio holds the maximum ratio of differing
+	// pixels of two matching images. See WantFile.
+	PixelTolerance uint8
+	MaxPixelRatio  float64
+
+	// IgnoreColumns holds the names of the columns skipped by the comparison of
+	// CSV and TSV gold masters, and KeyColumn, if not empty, holds the name of
+	// the column whose values match the rows regardless of their order.
+	IgnoreColumns []string
+	KeyColumn     string
+
+	// AbsTolerance and RelTolerance hold the absolute and relative tolerances
+	// of the numbers compared by the non-empty literal and the gold master
+	// smart validation strings of the WantStdout and WantStderr fields and of
+	// the WantJSON values, if either one is not zero. Two numbers match
+	// if their difference does not exceed either tolerance, the relative one
+	// being scaled by the largest magnitude.
+	AbsTolerance float64
+	RelTolerance float64
+
+	// Unordered enables matching the lines of the outputs to the non-empty
+	// literal and the gold master smart validation strings of the WantStdout
+	// and WantStderr fields and of the WantJSON values regardless of their
+	// order, as a multiset. The tolerances are then ignored.
+	Unordered bool
+
+	// Vars holds the variables of the template gold masters, with the .tmpl
+	// extension, such as the version or the temporary directory of the command
+	// under test.
+	Vars map[string]interface{}
+
 	// WantStdout and WantStderr hold a smart validation string for the expected
 	// standard and error output, respectively.
 	WantStdout string
 	WantStderr string
 
+	// WantJSON holds the smart validation strings for the values of queries
+	// applied to the standard output, which is parsed as JSON. A query is a
+	// list of filters separated by the "|" character: a path, such as
+	// ".items[0].name", ".items[-1]" or `.["a key"]`, or one of the length and
+	// keys functions, such as ".items | length". String values are matched as
+	// is, other values by their compact JSON encoding. The gold master of a
+	// query is named after it, such as TestXxx-output.items-0-name.json for
+	// the query ".items[0].name" and "golden.json". If WantStdout is empty,
+	// the standard output is not matched as a string.
+	WantJSON map[string]string
+
+	// WantLogs, if not nil, holds the expected records of the error output,
+	// which is parsed as JSON Lines. Each record is represented by a list of
+	// conditions separated by spaces: a dotted field path optionally followed
+	// by "=" and the expected value, or by "~" and a regular expression
+	// matching a part of the value, such as:
+	//
+	//	level=error msg~"timeout" attempt=3 error.retry=true
+	//
+	// Values and regular expressions containing spaces are written as JSON
+	// strings. Unless LogSubset is true, the records must match the list one
+	// by one, else the list must match a subsequence of the records. Fields
+	// without a condition, such as the volatile time field, are ignored. If
+	// WantStderr is empty, the error output is not matched as a string.
+	WantLogs  []string
+	LogSubset bool
+
+	// Normalize holds the normalizers applied, in order, to the standard and
+	// error outputs before matching, such as StripANSI or Snapshot.
+	Normalize []Normalizer
+
 	// WantPanic and WantErr hold a smart validation string for the expected
 	// panic and error message, respectively.
 	WantPanic string
@@ -136,6 +417,31 @@ type Case struct {
 
 	// WantExitCode holds the expected exit code.
 	WantExitCode int
+
+	// WantExit holds a smart exit code expectation. If not empty, it overrides
+	// WantExitCode. See the package documentation for the syntax.
+	WantExit string
+
+	// Signal, if not nil, holds the signal sent to the command under test after
+	// SignalDelay has elapsed since the start or, if SignalAfter is not empty,
+	// since a standard output line matched the SignalAfter smart validation
+	// string. The command must implement the Signaler interface.
+	Signal      os.Signal
+	SignalDelay time.Duration
+	SignalAfter string
+
+	// WantSignal holds a smart validation string for the name of the signal
+	// that terminated the command, as reported by the ExitSignaler interface.
+	WantSignal string
+
+	// MaxDuration, if not zero, holds the maximum duration of the run. MaxRSS
+	// and MaxCPU, if not zero, hold the maximum resident set size, in bytes,
+	// and the maximum user and system CPU time of the run, as reported by the
+	// UsageReporter interface, which is implemented by the runners generated
+	// by Program and Terminal on Linux.
+	MaxDuration time.Duration
+	MaxRSS      int64
+	MaxCPU      time.Duration
 }
 
 // TmpFiles adds to all the named files the full path to a new temporary
@@ -144,7 +450,7 @@ type Case struct {
 //
 //     defer TmpFiles(t, name1, name2, ...)()
 //
-func TmpFiles(t *testing.T, names ...*string) func() {
+func TmpFiles(t testing.TB, names ...*string) func() {
 	dir, err := ioutil.TempDir("", "go-golden")
 	if err != nil {
 		t.Fatal(err)
@@ -160,49 +466,122 @@ func TmpFiles(t *testing.T, names ...*string) func() {
 // Test tests the specified command by running a subtest for each listed case
 // with the provided argument list. If the command outputs do not match the
 // expected ones, the subtest signals a failure and reports an error for each
-// invalid output.
-func Test(t *testing.T, command Runner, testCases []Case) {
+// invalid output. Subtests are run by the Run method of a *testing.T, a
+// *testing.B or a custom harness implementing the SubtestRunner interface,
+// else the test fails: cases run by the same t would share their gold masters.
+func Test(t testing.TB, command Runner, testCases []Case) {
 	t.Helper()
 
 	for _, tc := range testCases {
-		t.Run(tc.Name, func(t *testing.T) {
+		subtest(t, tc.Name, func(t testing.TB) {
 			t.Helper() // TODO: make Helper working for subtests: issue #24128
 
-			stdout := &bytes.Buffer{}
-			stderr := &bytes.Buffer{}
+			testCase(t, command, tc)
+		})
+	}
+}
+
+// SubtestRunner is the optional interface implemented by a custom test harness
+// that runs subtests.
+type SubtestRunner interface {
+	// Run runs f as a subtest with the specified name and reports whether f
+	// succeeded.
+	Run(name string, f func(t testing.TB)) bool
+}
+
+// subtest runs f as a subtest of t with the specified name. The test fails if
+// subtests are not supported.
+func subtest(t testing.TB, name string, f func(t testing.TB)) {
+	switch t := t.(type) {
+	case *testing.T:
+		t.Run(name, func(t *testing.T) { f(t) })
+	case *testing.B:
+		t.Run(name, func(b *testing.B) { f(b) })
+	case SubtestRunner:
+		t.Run(name, f)
+	default:
+		t.Helper()
+		t.Fatal("Test error:\nharness does not implement SubtestRunner")
+	}
+}
+
+// testCase tests the command with the test case.
+func testCase(t testing.TB, command Runner, tc Case) {
+	t.Helper()
 
-			command.SetStdout(stdout)
-			command.SetStderr(stderr)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
 
-			m := newMatch(t, tc.wantFail)
+	m := newMatch(t, tc.wantFail)
+	m.ignoreColumns, m.keyColumn = tc.IgnoreColumns, tc.KeyColumn
+	m.absTolerance, m.relTolerance = tc.AbsTolerance, tc.RelTolerance
+	m.unordered = tc.Unordered
+	m.vars = tc.Vars
 
-			if tc.WantFile != "" {
-				if !m.removeFile(tc.WantFile) {
-					tc.WantFile = "" // stop testing File match
-				}
-			}
+	m.setStdin(command, tc.Stdin)
 
-			var gotErr string
-			gotPanic := m.run(func() {
-				if err := command.Run(tc.Args); err != nil {
-					gotErr = err.Error()
-				}
-			})
-
-			if tc.WantFile != "" {
-				if gotFile, ext, ok := m.getFile(tc.WantFile); ok {
-					m.match("File golden"+ext, gotFile, "golden"+ext)
-				}
+	w, cleanup := m.setSignal(command, tc, stdout)
+	command.SetStdout(w)
+	command.SetStderr(stderr)
+
+	if tc.WantFile != "" {
+		if !m.removeFile(tc.WantFile) {
+			tc.WantFile = "" // stop testing File match
+		}
+	}
+
+	var gotErr string
+	start := time.Now()
+	gotPanic := m.run(func() {
+		if err := command.Run(tc.Args); err != nil {
+			gotErr = err.Error()
+		}
+	})
+	duration := time.Since(start)
+	cleanup()
+
+	if tc.WantFile != "" {
+		if gotFile, ext, ok := m.getFile(tc.WantFile); ok {
+			if archive := archiveExt(tc.WantFile); archive != "" {
+				m.matchArchive(gotFile, archive, tc.FileModes)
+			} else if isImage(tc.WantFile) {
+				m.matchImage(gotFile, ext, tc.PixelTolerance, tc.MaxPixelRatio)
+			} else {
+				m.match("File golden"+ext, gotFile, "golden"+ext)
 			}
+		}
+	}
 
-			m.match("WantStdout", stdout.String(), tc.WantStdout)
-			m.match("WantStderr", stderr.String(), tc.WantStderr)
-			m.match("WantPanic", gotPanic, tc.WantPanic)
-			m.match("WantErr", gotErr, tc.WantErr)
-			m.equal("WantExitCode", command.ExitCode(), tc.WantExitCode)
+	gotStdout := normalize(stdout.String(), tc.Normalize)
+	gotStderr := normalize(stderr.String(), tc.Normalize)
+	gotSignal := signalName(command)
 
-			m.done()
-		})
+	if len(tc.WantJSON) == 0 || tc.WantStdout != "" {
+		m.matchOutput("WantStdout", gotStdout, tc.WantStdout)
+	}
+	m.queries(gotStdout, tc.WantJSON)
+	if tc.WantLogs == nil || tc.WantStderr != "" {
+		m.matchOutput("WantStderr", gotStderr, tc.WantStderr)
+	}
+	m.records(gotStderr, tc.WantLogs, tc.LogSubset)
+	m.match("WantPanic", gotPanic, tc.WantPanic)
+	m.match("WantErr", gotErr, tc.WantErr)
+	m.exit(command, tc.WantExitCode, tc.WantExit)
+	m.match("WantSignal", gotSignal, tc.WantSignal)
+	m.limits(command, tc, duration)
+
+	failed := m.failed()
+	m.report(tc, outputs{
+		Stdout:   gotStdout,
+		Stderr:   gotStderr,
+		Panic:    gotPanic,
+		Err:      gotErr,
+		ExitCode: command.ExitCode(),
+		Signal:   gotSignal,
+	}, !failed)
+
+	if failed {
+		m.fail()
 	}
 }
 
@@ -225,51 +604,123 @@ type Runner interface {
 	ExitCode() int
 }
 
+// ExitSignaler is the optional interface implemented by a Runner whose last run
+// may be terminated by a signal.
+type ExitSignaler interface {
+	// ExitSignal returns the signal that terminated the last run, or nil if
+	// the last run was not terminated by a signal.
+	ExitSignal() os.Signal
+}
+
 // program implements a Runner for an external program.
 type program struct {
-	name     string    // program name
-	env      []string  // process environment
-	stdout   io.Writer // standard output
-	stderr   io.Writer // standard error
-	exitCode int       // exit code
+	name     string           // program name
+	env      []string         // process environment
+	stdin    io.Reader        // standard input
+	stdout   io.Writer        // standard output
+	stderr   io.Writer        // standard error
+	exitCode int              // exit code
+	signal   os.Signal        // exit signal
+	state    *os.ProcessState // exit state
+
+	sendSignal os.Signal       // signal to be sent
+	trigger    <-chan struct{} // closed to send the signal
 }
 
 func (p *program) Run(args []string) error {
+	cmd, err := p.command(args)
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdin = p.stdin
+	cmd.Stdout = p.stdout
+	cmd.Stderr = p.stderr
+
+	return p.run(cmd, nil)
+}
+
+// command returns the command for the specified argument list.
+func (p *program) command(args []string) (*exec.Cmd, error) {
 	p.exitCode = 2
+	p.signal = nil
+	p.state = nil
 
 	if len(args) == 0 {
-		return errors.New("missing program name")
+		return nil, errors.New("missing program name")
 	}
 	if args[0] != p.name {
-		return errors.New("invalid program name: " + args[0])
+		return nil, errors.New("invalid program name: " + args[0])
 	}
 
 	p.exitCode = 0
 
 	cmd := exec.Command(p.name, args[1:]...)
-	cmd.Stdout = p.stdout
-	cmd.Stderr = p.stderr
 	cmd.Env = p.env
+	return cmd, nil
+}
+
+// run starts the command, sends the signal when triggered and waits for the
+// command to exit. The started function, if not nil, is called after start.
+func (p *program) run(cmd *exec.Cmd, started func()) error {
+	if err := cmd.Start(); err != nil {
+		p.exitCode = -1
+		return err
+	}
+
+	if started != nil {
+		started()
+	}
+
+	done := make(chan struct{})
+	if p.sendSignal != nil {
+		go func(sig os.Signal, trigger <-chan struct{}) {
+			select {
+			case <-trigger:
+				cmd.Process.Signal(sig)
+			case <-done:
+			}
+		}(p.sendSignal, p.trigger)
+	}
+
+	err := cmd.Wait()
+	close(done)
+	p.state = cmd.ProcessState
 
-	err := cmd.Run()
 	if err != nil {
 		type status interface {
 			ExitStatus() int
 		}
 
+		type signaled interface {
+			Signaled() bool
+			Signal() syscall.Signal
+		}
+
 		p.exitCode = -1
 
 		if s, ok := cmd.ProcessState.Sys().(status); ok {
 			p.exitCode = s.ExitStatus()
 		}
+
+		if s, ok := cmd.ProcessState.Sys().(signaled); ok && s.Signaled() {
+			p.signal = s.Signal()
+		}
 	}
 
 	return err
 }
 
+func (p *program) SetStdin(r io.Reader)  { p.stdin = r }
 func (p *program) SetStdout(w io.Writer) { p.stdout = w }
 func (p *program) SetStderr(w io.Writer) { p.stderr = w }
 func (p *program) ExitCode() int         { return p.exitCode }
+func (p *program) ExitSignal() os.Signal { return p.signal }
+
+func (p *program) SetSignal(sig os.Signal, trigger <-chan struct{}) {
+	p.sendSignal = sig
+	p.trigger = trigger
+}
 
 // Program returns a Runner for the named program and the specified process
 // environment. See exec.Command and exec.Cmd.Env for valid name and env values.
@@ -7,14 +7,30 @@
 package golden_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	. "github.com/larhun/golden"
 )
@@ -39,6 +55,65 @@ func mock() int {
 	case "stderr":
 		fmt.Fprint(os.Stderr, value)
 		return 1
+	case "kill":
+		p, _ := os.FindProcess(os.Getpid())
+		p.Kill()
+		select {}
+	case "trap":
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		fmt.Fprintln(os.Stdout, "ready")
+		fmt.Fprintln(os.Stdout, "cleanup:", <-c)
+		return 130
+	case "sleep":
+		time.Sleep(time.Minute)
+		return 0
+	case "repl":
+		scanner := bufio.NewScanner(os.Stdin)
+		for fmt.Fprint(os.Stdout, "> "); scanner.Scan(); fmt.Fprint(os.Stdout, "> ") {
+			switch line := scanner.Text(); line {
+			case "quit":
+				fmt.Fprintln(os.Stdout, "bye")
+				return 0
+			case "fail":
+				fmt.Fprintln(os.Stderr, "error: fail")
+			default:
+				fmt.Fprintln(os.Stdout, strings.ToUpper(line))
+			}
+		}
+		return 1
+	case "fetch":
+		var body io.Reader
+		if len(os.Args) > 4 {
+			body = strings.NewReader(os.Args[4])
+		}
+		req, err := http.NewRequest(os.Args[2], os.Getenv("GOLDEN_TEST_URL")+os.Args[3], body)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer resp.Body.Close()
+		fmt.Fprintln(os.Stdout, resp.StatusCode)
+		io.Copy(os.Stdout, resp.Body)
+		return 0
+	case "tty":
+		if stat, err := os.Stdout.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprintln(os.Stdout, "terminal")
+		} else {
+			fmt.Fprintln(os.Stdout, "pipe")
+		}
+		fmt.Fprintln(os.Stdout, "\x1b[31mred\x1b[0m")
+		fmt.Fprintln(os.Stdout, "progress 10%\rprogress 100%")
+		fmt.Fprintln(os.Stderr, strings.Repeat("-", 30))
+		return 0
+	case "scroll":
+		fmt.Fprint(os.Stdout, "top\nnext\x1b[6;1H\nlast")
+		return 0
 	}
 
 	panic("invalid command name: " + os.Args[1])
@@ -90,11 +165,80 @@ func (e *echo) Run(args []string) error {
 		if err := ioutil.WriteFile(args[2], []byte(filepath.Base(args[2])), 0666); err != nil {
 			panic(err)
 		}
+
+	case "archive":
+		inner := writeArchive(".zip", [][2]string{{"c.txt", "ccc"}})
+		data := writeArchive(filepath.Ext(args[2]), [][2]string{
+			{"a.txt", "hello\n"},
+			{"bin", "\x00\x01"},
+			{"dir/b.txt", "value..."},
+			{"inner.zip", string(inner)},
+		})
+		if err := ioutil.WriteFile(args[2], data, 0666); err != nil {
+			panic(err)
+		}
+
+	case "image":
+		if err := ioutil.WriteFile(args[2], writeImage(args[3:]), 0666); err != nil {
+			panic(err)
+		}
 	}
 
 	return nil
 }
 
+// writeImage returns a 4x4 PNG gradient image. The "noisy" variant shifts the
+// red channel of all pixels by 3 and the "spot" variant paints one pixel white.
+func writeImage(variants []string) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			img.Set(x, y, color.RGBA{uint8(40 * x), uint8(40 * y), 100, 255})
+		}
+	}
+
+	for _, v := range variants {
+		switch v {
+		case "noisy":
+			for i := 0; i < len(img.Pix); i += 4 {
+				img.Pix[i] += 3
+			}
+		case "spot":
+			img.Set(2, 1, color.White)
+		}
+	}
+
+	b := &bytes.Buffer{}
+	png.Encode(b, img)
+	return b.Bytes()
+}
+
+// writeArchive returns a zip or gzipped tar archive of the named contents.
+func writeArchive(ext string, files [][2]string) []byte {
+	b := &bytes.Buffer{}
+	if ext == ".zip" {
+		w := zip.NewWriter(b)
+		for _, f := range files {
+			h := &zip.FileHeader{Name: f[0], Method: zip.Deflate, Modified: time.Now()}
+			h.SetMode(0644)
+			fw, _ := w.CreateHeader(h)
+			fw.Write([]byte(f[1]))
+		}
+		w.Close()
+		return b.Bytes()
+	}
+
+	gz := gzip.NewWriter(b)
+	w := tar.NewWriter(gz)
+	for _, f := range files {
+		w.WriteHeader(&tar.Header{Name: f[0], Mode: 0600, Size: int64(len(f[1])), ModTime: time.Now()})
+		w.Write([]byte(f[1]))
+	}
+	w.Close()
+	gz.Close()
+	return b.Bytes()
+}
+
 func (e *echo) SetStdout(w io.Writer) { e.stdout = w }
 func (e *echo) SetStderr(w io.Writer) { e.stderr = w }
 func (e echo) ExitCode() int          { return e.exitCode }
@@ -104,7 +248,7 @@ func TestMain(m *testing.M) {
 		os.Exit(mock())
 	}
 
-	os.Exit(m.Run())
+	Main(m)
 }
 
 func TestEqual(t *testing.T) {
@@ -169,6 +313,10 @@ func TestGolden(t *testing.T) {
 			Args:         []string{"echo", "err", "err"},
 			WantErr:      "golden.err",
 			WantExitCode: 3,
+		}, {
+			Args:         []string{"echo", "stdout", "\x00\x01\xfe\xff"},
+			WantStdout:   "golden.bin",
+			WantExitCode: 0,
 		},
 	})
 }
@@ -239,6 +387,58 @@ func TestEscaped(t *testing.T) {
 	})
 }
 
+func TestExit(t *testing.T) {
+	Test(t, new(echo), []Case{
+		{
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "value",
+			WantExit:   "any",
+		}, {
+			Args:       []string{"echo", "stderr", "value"},
+			WantStderr: "value",
+			WantExit:   "nonzero",
+		}, {
+			Args:      []string{"echo", "panic", "value"},
+			WantPanic: "value",
+			WantExit:  "1|2",
+		}, {
+			Args:     []string{"echo", "err", "value"},
+			WantErr:  "value",
+			WantExit: "0|2..3",
+		}, {
+			Args:     []string{"echo", "exit"},
+			WantExit: "-1..125",
+		},
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	Test(t, new(echo), []Case{
+		{
+			Args:       []string{"echo", "stdout", "\x1b[1;31mred\x1b[0m \x1b[2Kplain\x1b]0;title\x07"},
+			Normalize:  []Normalizer{StripANSI},
+			WantStdout: "red plain",
+		}, {
+			Args:       []string{"echo", "stdout", "\x1b[1;31mred\x1b[32mgreen\x1b[22m\x1b[39m \x1b[38;5;208m\x1b[48;2;0;0;255mcolor\x1b[0m"},
+			Normalize:  []Normalizer{SymbolizeANSI},
+			WantStdout: "<bold><red>red</red><green>green</green></bold> <color-208><bg-#0000ff>color</bg-#0000ff></color-208>",
+		}, {
+			Args:       []string{"echo", "stderr", "\x1b[1mbold\x1b[1m\x1b[4m\x1b[22mline\x1b[0m"},
+			Normalize:  []Normalizer{SymbolizeANSI},
+			WantStderr: "<bold>bold</bold><underline>line</underline>",
+			WantExit:   "1",
+		}, {
+			Args:       []string{"echo", "stdout", "progress 10%\rprogress 100%", "\x1b[1Adone\x1b[K\tok\n"},
+			Normalize:  []Normalizer{Snapshot},
+			WantStdout: "done    ok\n",
+		}, {
+			Args:       []string{"echo", "stdout", "\x1b[32mok\x1b[0m", "bar [=====>    ]\x1b[6D=====]\x1b[s"},
+			Normalize:  []Normalizer{SymbolizeANSI, Snapshot},
+			WantStdout: "<green>ok</green>\nbar [==========]\n",
+		},
+	})
+}
+
 func TestFile(t *testing.T) {
 	found, missing := "found", "missing"
 	defer TmpFiles(t, &found, &missing)()
@@ -260,6 +460,667 @@ func TestFile(t *testing.T) {
 	})
 }
 
+func TestArchive(t *testing.T) {
+	zipFile, tgzFile := "file.zip", "file.tgz"
+	defer TmpFiles(t, &zipFile, &tgzFile)()
+	defer os.Remove(filepath.Join("testdata", "golden", "TestArchive-fail.zip.txtar.actual"))
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:     "zip",
+			Args:     []string{"echo", "archive", zipFile},
+			WantFile: zipFile,
+		}, {
+			Name:      "tgz",
+			Args:      []string{"echo", "archive", tgzFile},
+			WantFile:  tgzFile,
+			FileModes: true,
+		}, {
+			Name:     "smart",
+			Args:     []string{"echo", "archive", zipFile},
+			WantFile: zipFile,
+		}, {
+			Name:     "fail",
+			Args:     []string{"echo", "archive", zipFile},
+			WantFile: zipFile,
+			WantFail: ptrTo(`File golden.zip.txtar match error:
+entry dir/b.txt match error:
+got: value...
+want: value
+missing entry: missing.txt
+unexpected entry: inner.zip/c.txt`),
+		},
+	}))
+}
+
+func TestImage(t *testing.T) {
+	file := "file.png"
+	defer TmpFiles(t, &file)()
+	diff := filepath.Join("testdata", "golden", "TestImage-fail.png.diff.png")
+	defer os.Remove(diff)
+	defer os.Remove(filepath.Join("testdata", "golden", "TestImage-fail.png.actual"))
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:     "exact",
+			Args:     []string{"echo", "image", file},
+			WantFile: file,
+		}, {
+			Name:           "noisy",
+			Args:           []string{"echo", "image", file, "noisy"},
+			WantFile:       file,
+			PixelTolerance: 3,
+		}, {
+			Name:          "spot",
+			Args:          []string{"echo", "image", file, "spot"},
+			WantFile:      file,
+			MaxPixelRatio: 0.1,
+		}, {
+			Name:     "fail",
+			Args:     []string{"echo", "image", file, "noisy", "spot"},
+			WantFile: file,
+			WantFail: ptrTo(`File golden.png match error:
+16 of 16 pixels differ (100.00%), want at most 0.00%
+difference image: ` + diff),
+		},
+	}))
+
+	if _, err := os.Stat(diff); err != nil {
+		t.Error("missing difference image: " + err.Error())
+	}
+
+	// a directory in place of the difference image
+	unwritable := filepath.Join("testdata", "golden", "TestImage-unwritable.png.diff.png")
+	if err := os.MkdirAll(unwritable, 0700); err != nil {
+		t.Fatal("cannot write temporary directory: " + unwritable)
+	}
+	defer os.Remove(unwritable)
+	defer os.Remove(filepath.Join("testdata", "golden", "TestImage-unwritable.png.actual"))
+
+	f := &failing{TB: t, name: t.Name()}
+	Test(f, new(echo), []Case{
+		{
+			Name:     "unwritable",
+			Args:     []string{"echo", "image", file, "noisy", "spot"},
+			WantFile: file,
+		},
+	})
+	if got := strings.Join(f.errors, "\n"); !strings.Contains(got, "File golden.png write error:") {
+		t.Errorf("missing difference image write error:\n%s", got)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	defer os.Remove(filepath.Join("testdata", "golden", "TestConfig-fail.yaml.actual"))
+	defer os.Remove(filepath.Join("testdata", "golden", "TestConfig-parse.yaml.actual"))
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "yaml",
+			Args:       []string{"echo", "stdout", "b: [1, 2]\na:\n  x: 'text'"},
+			WantStdout: "golden.yaml",
+		}, {
+			Name:       "toml",
+			Args:       []string{"echo", "stdout", "title = \"x\"\n[server]\nport = 80"},
+			WantStdout: "golden.toml",
+		}, {
+			Name:       "fail",
+			Args:       []string{"echo", "stdout", "a:\n  x: text\n  y: 1\nb: [1]\nc: true"},
+			WantStdout: "golden.yaml",
+			WantFail: ptrTo(`WantStdout golden.yaml match error:
+a.x: got text, want other
+unexpected key: a.y
+missing item: b[1]
+missing key: d
+unexpected key: c`),
+		}, {
+			Name:       "parse",
+			Args:       []string{"echo", "stdout", "a: [1"},
+			WantStdout: "golden.yaml",
+			WantFail:   ptrTo("WantStdout golden.yaml parse error:\ngot: line 1: unterminated flow collection"),
+		},
+	}))
+}
+
+func TestMarkup(t *testing.T) {
+	defer os.Remove(filepath.Join("testdata", "golden", "TestMarkup-fail.xml.actual"))
+	defer os.Remove(filepath.Join("testdata", "golden", "TestMarkup-prefail.html.actual"))
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "xml",
+			Args:       []string{"echo", "stdout", `<?xml version="1.0"?><r:root xmlns:r="urn:x" b="2" a="1"><r:item>  one  </r:item><!-- c --><r:item>two</r:item></r:root>`},
+			WantStdout: "golden.xml",
+		}, {
+			Name:       "html",
+			Args:       []string{"echo", "stdout", `<!DOCTYPE html><P CLASS="x">Tom &amp; Jerry<br><b>Show</b></P>`},
+			WantStdout: "golden.html",
+		}, {
+			Name:       "pre",
+			Args:       []string{"echo", "stdout", "<div><PRE>  a\n    <b>b  </b></PRE><textarea> c\n d</textarea><p>  e\n   f </p></div>"},
+			WantStdout: "golden.html",
+		}, {
+			Name:       "prefail",
+			Args:       []string{"echo", "stdout", "<pre>a  b</pre>"},
+			WantStdout: "golden.html",
+			WantFail:   ptrTo(`WantStdout golden.html match error:` + "\n" + `/pre/text(): got text "a  b", want text "a b"`),
+		}, {
+			Name:       "fail",
+			Args:       []string{"echo", "stdout", `<root><item id="1">one</item><item id="3">two</item></root>`},
+			WantStdout: "golden.xml",
+			WantFail:   ptrTo(`WantStdout golden.xml match error:` + "\n" + `/root/item[2]/@id: got "3", want "2"`),
+		},
+	}))
+}
+
+func TestTable(t *testing.T) {
+	dir := filepath.Join("testdata", "golden")
+	defer os.Remove(filepath.Join(dir, "TestTable-fail.csv.actual"))
+	defer os.Remove(filepath.Join(dir, "TestTable-key.csv.actual"))
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:          "ignore",
+			Args:          []string{"echo", "stdout", "id,total,time\n1,10,09:12\n2,20,09:13"},
+			WantStdout:    "golden.csv",
+			IgnoreColumns: []string{"time"},
+		}, {
+			Name:       "order",
+			Args:       []string{"echo", "stdout", "id\ttotal\n2\t20\n1\t10"},
+			WantStdout: "golden.tsv",
+			KeyColumn:  "id",
+		}, {
+			Name:       "fail",
+			Args:       []string{"echo", "stdout", "id,total\n1,10\n2,25\n3,30"},
+			WantStdout: "golden.csv",
+			WantFail: ptrTo(`WantStdout golden.csv match error:
+row 2, column "total": got 25, want 20
+unexpected row 3`),
+		}, {
+			Name:       "key",
+			Args:       []string{"echo", "stdout", "id,total\n2,25\n4,40"},
+			WantStdout: "golden.csv",
+			KeyColumn:  "id",
+			WantFail: ptrTo(`WantStdout golden.csv match error:
+row 1 (id=2), column "total": got 25, want 20
+unexpected row 2 (id=4)
+missing row (id=1)`),
+		},
+	}))
+}
+
+func TestParseError(t *testing.T) {
+	report := "report.json"
+	defer TmpFiles(t, &report)()
+	defer SetReport(report)()
+
+	defer flag.Set("update", flag.Lookup("update").Value.String())
+	flag.Set("update", "true")
+
+	dir := filepath.Join("testdata", "golden")
+	defer os.Remove(filepath.Join(dir, "TestParseError-yaml.yaml"))
+	defer os.Remove(filepath.Join(dir, "TestParseError-yaml.yaml.actual"))
+	defer os.Remove(filepath.Join(dir, "TestParseError-csv.csv"))
+	defer os.Remove(filepath.Join(dir, "TestParseError-csv.csv.actual"))
+
+	f := &failing{TB: t, name: t.Name()}
+	Test(f, new(echo), []Case{
+		{
+			Name:       "yaml",
+			Args:       []string{"echo", "stdout", "a: [1"},
+			WantStdout: "golden.yaml",
+		}, {
+			Name:       "csv",
+			Args:       []string{"echo", "stdout", "a,b\n1,x\"y"},
+			WantStdout: "golden.csv",
+		}, {
+			Name:     "logs",
+			Args:     []string{"echo", "stderr", "{"},
+			WantLogs: []string{"level=info"},
+		}, {
+			Name:     "expr",
+			Args:     []string{"echo", "stderr", "{}"},
+			WantLogs: []string{"msg~("},
+		}, {
+			Name:     "json",
+			Args:     []string{"echo", "stdout", "{"},
+			WantJSON: map[string]string{".a": "1"},
+		}, {
+			Name:     "query",
+			Args:     []string{"echo", "stdout", "{}"},
+			WantJSON: map[string]string{".a": "1"},
+		},
+	})
+
+	if err := WriteReports(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Cases []struct {
+			Test   string
+			Checks []struct {
+				Name, Matcher, File, Message string
+				Pass                         bool
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []struct {
+		name, matcher, message, file, got string
+	}{
+		{"WantStdout", "yaml", "parse error", "TestParseError-yaml.yaml", "a: [1"},
+		{"WantStdout", "csv", "parse error", "TestParseError-csv.csv", "a,b\n1,x\"y"},
+		{"WantLogs", "records", "parse error", "", ""},
+		{"WantLogs", "records", "syntax error", "", ""},
+		{"WantJSON", "query", "parse error", "", ""},
+		{"WantJSON .a", "query", "query error", "", ""},
+	} {
+		failed := -1
+		for j := 0; i < len(got.Cases) && j < len(got.Cases[i].Checks); j++ {
+			if !got.Cases[i].Checks[j].Pass {
+				failed = j
+				break
+			}
+		}
+		if failed < 0 {
+			t.Errorf("Parse error: missing check %s %s", want.name, want.matcher)
+			continue
+		}
+
+		c := got.Cases[i].Checks[failed]
+		if c.Name != want.name || c.Matcher != want.matcher || !strings.Contains(c.Message, want.message) {
+			t.Errorf("Parse error: unexpected check: %+v", c)
+		}
+		if want.file == "" {
+			continue
+		}
+		if c.File != filepath.Join(dir, want.file) {
+			t.Errorf("Parse error: got file %s, want %s", c.File, want.file)
+		}
+		if data, err := ioutil.ReadFile(filepath.Join(dir, want.file)); err != nil || string(data) != want.got {
+			t.Errorf("Parse error: gold master %s not updated", want.file)
+		}
+	}
+}
+
+func TestTolerance(t *testing.T) {
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:         "abs",
+			Args:         []string{"echo", "stdout", "pi = 3.141592653"},
+			WantStdout:   "pi = 3.14159",
+			AbsTolerance: 1e-5,
+		}, {
+			Name:         "rel",
+			Args:         []string{"echo", "stdout", "x 1000.4 y 2e-3", "z -0.5"},
+			WantStdout:   "x 1000 y 0.002\nz -.5",
+			RelTolerance: 1e-3,
+		}, {
+			Name:         "golden",
+			Args:         []string{"echo", "stdout", "sum: 0.30000000000000004"},
+			WantStdout:   "golden.txt",
+			RelTolerance: 1e-9,
+		}, {
+			Name:         "number",
+			Args:         []string{"echo", "stdout", "a 1.5 b 2.5"},
+			WantStdout:   "a 1.25 b 2.5",
+			AbsTolerance: 0.1,
+			WantFail:     ptrTo("WantStdout tolerance match error:\nline 1: got 1.5, want 1.25 (difference 0.25)"),
+		}, {
+			Name:         "text",
+			Args:         []string{"echo", "stdout", "a 1", "b"},
+			WantStdout:   "a 1\nc",
+			AbsTolerance: 0.1,
+			WantFail:     ptrTo(`WantStdout tolerance match error:` + "\n" + `line 1: got "\nb", want "\nc"`),
+		}, {
+			Name:         "exact",
+			Args:         []string{"echo", "stdout", "1.0"},
+			WantStdout:   "^1\\.0$",
+			AbsTolerance: 0.1,
+		}, {
+			Name:       "literal",
+			Args:       []string{"echo", "stdout", "~/bin"},
+			WantStdout: "~/bin",
+		}, {
+			Name:         "err",
+			Args:         []string{"echo", "err", "exit status 2"},
+			WantErr:      "exit status 1",
+			WantExitCode: 3,
+			AbsTolerance: 1,
+			WantFail:     ptrTo("WantErr match error:..."),
+		},
+	}))
+}
+
+func TestUnordered(t *testing.T) {
+	defer os.Remove(filepath.Join("testdata", "golden", "TestUnordered-fail.txt.actual"))
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "inline",
+			Args:       []string{"echo", "stdout", "b", "a", "b"},
+			WantStdout: "b\nb\na",
+			Unordered:  true,
+		}, {
+			Name:       "golden",
+			Args:       []string{"echo", "stdout", "c", "a", "b\n"},
+			WantStdout: "golden.txt",
+			Unordered:  true,
+		}, {
+			Name:       "fail",
+			Args:       []string{"echo", "stdout", "c", "x", "a"},
+			WantStdout: "golden.txt",
+			Unordered:  true,
+			WantFail: ptrTo(`WantStdout golden.txt match error:
+missing line: b
+unexpected line: x`),
+		}, {
+			Name:       "literal",
+			Args:       []string{"echo", "stdout", "* item", "* other"},
+			WantStdout: "* item\n* other",
+		}, {
+			Name:       "ordered",
+			Args:       []string{"echo", "stdout", "* other", "* item"},
+			WantStdout: "* item\n* other",
+			WantFail:   ptrTo("WantStdout match error:..."),
+		}, {
+			Name:         "err",
+			Args:         []string{"echo", "err", "b", "a"},
+			WantErr:      "a\nb",
+			WantExitCode: 3,
+			Unordered:    true,
+			WantFail:     ptrTo("WantErr match error:..."),
+		},
+	}))
+}
+
+func TestLogs(t *testing.T) {
+	logs := []string{"echo", "stderr",
+		`{"time":"10:00:01","level":"info","msg":"connecting to db"}`,
+		`{"time":"10:00:02","level":"error","msg":"dial timeout","attempt":3}`,
+	}
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:         "sequence",
+			Args:         logs,
+			WantStderr:   "golden.jsonl",
+			WantLogs:     []string{`level=info msg~"^connecting to"`, `level=error msg~timeout attempt=3`},
+			WantExitCode: 1,
+		}, {
+			Name:         "subset",
+			Args:         logs,
+			WantLogs:     []string{`level=error attempt`},
+			LogSubset:    true,
+			WantExitCode: 1,
+		}, {
+			Name:         "fail",
+			Args:         logs,
+			WantLogs:     []string{`level=info`, `level=warn msg~"time out"`},
+			WantExitCode: 1,
+			WantFail:     ptrTo(`WantLogs match error:` + "\n" + `record 2: field "level": got "error", want "warn"`),
+		}, {
+			Name:         "missing",
+			Args:         logs,
+			WantLogs:     []string{`level=error`, `level=info`},
+			LogSubset:    true,
+			WantExitCode: 1,
+			WantFail:     ptrTo("WantLogs match error:\nmissing record: level=info"),
+		}, {
+			Name:         "syntax",
+			Args:         logs,
+			WantLogs:     []string{`msg~(`},
+			WantExitCode: 1,
+			WantFail:     ptrTo("WantLogs syntax error:\nerror parsing regexp..."),
+		},
+	}))
+}
+
+func TestQueries(t *testing.T) {
+	doc := `{"status":"ok","items":[{"id":1},{"id":2},{"id":3}],"meta":{"took ms":12.5}}`
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name: "pass",
+			Args: []string{"echo", "stdout", doc},
+			WantJSON: map[string]string{
+				".status":          "ok",
+				".items | length":  "3",
+				".items[-1].id":    "3",
+				".items[0]":        `{"id":1}`,
+				`.meta["took ms"]`: "12",
+				".meta | keys":     `["took ms"]`,
+			},
+			AbsTolerance: 1,
+		}, {
+			Name: "golden",
+			Args: []string{"echo", "stdout", doc},
+			WantJSON: map[string]string{
+				".items":       "golden.json",
+				".items[0]":    "golden.json",
+				".meta | keys": "golden",
+			},
+		}, {
+			Name:     "fail",
+			Args:     []string{"echo", "stdout", doc},
+			WantJSON: map[string]string{".status": "error"},
+			WantFail: ptrTo("WantJSON .status match error:\ngot: ok\nwant: error"),
+		}, {
+			Name:     "missing",
+			Args:     []string{"echo", "stdout", doc},
+			WantJSON: map[string]string{".items[0].name": "a"},
+			WantFail: ptrTo("WantJSON .items[0].name query error:\nmissing field: name"),
+		}, {
+			Name:     "syntax",
+			Args:     []string{"echo", "stdout", doc},
+			WantJSON: map[string]string{".items[x]": "a"},
+			WantFail: ptrTo("WantJSON .items[x] syntax error:\ninvalid path: .items[x]"),
+		},
+	}))
+}
+
+func TestTemplate(t *testing.T) {
+	output := []string{"echo", "stdout", "version 1.2.3 in /tmp/golden-42", "built 2026-10-18 with 17 files"}
+	vars := map[string]interface{}{"Version": "1.2.3", "TmpDir": "/tmp/golden-42"}
+	defer os.Remove("testdata/golden/TestTemplate-fail.tmpl.actual")
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "pass",
+			Args:       output,
+			Vars:       vars,
+			WantStdout: "golden.tmpl",
+		}, {
+			Name:       "escape",
+			Args:       []string{"echo", "stdout", "{{.Version}}"},
+			WantStdout: "golden.tmpl",
+		}, {
+			Name:       "fail",
+			Args:       output,
+			Vars:       map[string]interface{}{"Version": "1.2.4", "TmpDir": "/tmp/golden-42"},
+			WantStdout: "golden.tmpl",
+			WantFail:   ptrTo(`^WantStdout golden\.tmpl match error:(?s:.*)want:\s+version 1\.2\.4 in /tmp/golden-42\s+built \{\{any\}\} with(?s:.*)$`),
+		}, {
+			Name:       "vars",
+			Args:       output,
+			WantStdout: "golden.tmpl",
+			WantFail:   ptrTo(`...map has no entry for key "Version"`),
+		},
+	}))
+}
+
+func TestRecorder(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/greeting" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "hello %s%s", r.URL.Query().Get("name"), body)
+	}))
+	defer upstream.Close()
+
+	rec := NewRecorder(t, upstream.URL)
+	defer rec.Close()
+
+	name := os.Args[0]
+	Test(t, Program(name, []string{"GOLDEN_TEST_MOCK=", rec.Env("GOLDEN_TEST_URL")}), []Case{
+		{
+			Args:       []string{name, "fetch", "GET", "/greeting?name=World"},
+			WantStdout: "200\nhello World",
+		}, {
+			Args:       []string{name, "fetch", "POST", "/greeting", "!"},
+			WantStdout: "200\nhello !",
+		}, {
+			Args:       []string{name, "fetch", "GET", "/missing"},
+			WantStdout: "404\n404 page not found\n",
+		},
+	})
+}
+
+func TestRecorderUnexpected(t *testing.T) {
+	if flag.Lookup("update").Value.String() == "true" {
+		t.Skip("nothing to replay")
+	}
+
+	rec := NewRecorder(t, "")
+	rec.SetWantFail(ptrTo("Recorder match error:\nunexpected request: GET http://example.com/"))
+	defer rec.Close()
+
+	name := os.Args[0]
+	Test(t, Program(name, []string{"GOLDEN_TEST_MOCK=", "HTTP_PROXY=" + rec.URL()}), []Case{
+		{
+			Args:       []string{name, "fetch", "GET", "http://example.com/"},
+			WantStdout: "502\nRecorder match error:\nunexpected request: GET http://example.com/\n",
+		},
+	})
+}
+
+// harness represents a custom test harness running subtests.
+type harness struct {
+	testing.TB
+	names []string // subtest names
+}
+
+func (h *harness) Run(name string, f func(t testing.TB)) bool {
+	h.names = append(h.names, name)
+	f(h)
+	return !h.Failed()
+}
+
+// plain represents a custom test harness without subtests, which records its
+// fatal errors and stops at the first one.
+type plain struct {
+	testing.TB
+	errors []string // fatal errors
+}
+
+func (p *plain) Fatal(args ...interface{}) {
+	p.errors = append(p.errors, fmt.Sprint(args...))
+	runtime.Goexit()
+}
+
+func TestHarness(t *testing.T) {
+	cases := []Case{
+		{
+			Name:       "stdout",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "value",
+		}, {
+			Name:         "stderr",
+			Args:         []string{"echo", "stderr", "value"},
+			WantStderr:   "value",
+			WantExitCode: 1,
+		},
+	}
+
+	h := &harness{TB: t}
+	Test(h, new(echo), cases)
+	if got, want := strings.Join(h.names, ","), "stdout,stderr"; got != want {
+		t.Errorf("Run error: got %q, want %q", got, want)
+	}
+
+	p := &plain{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Test(p, new(echo), cases)
+	}()
+	<-done
+	if got, want := strings.Join(p.errors, ","), "Test error:\nharness does not implement SubtestRunner"; got != want {
+		t.Errorf("Run error: got %q, want %q", got, want)
+	}
+}
+
+func BenchmarkEcho(b *testing.B) {
+	Benchmark(b, new(echo), []Case{
+		{
+			Name:       "stdout",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "value",
+		}, {
+			Name:         "stderr",
+			Args:         []string{"echo", "stderr", "value"},
+			WantStderr:   "value",
+			WantExitCode: 1,
+		},
+	})
+}
+
+func TestLimits(t *testing.T) {
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:        "duration",
+			Args:        []string{"echo", "stdout", "value"},
+			WantStdout:  "value",
+			MaxDuration: time.Minute,
+		}, {
+			Name:        "slow",
+			Args:        []string{"echo", "stdout", "value"},
+			WantStdout:  "value",
+			MaxDuration: time.Nanosecond,
+			WantFail:    ptrTo(`^MaxDuration match error:\ngot: \S+, want: at most 1ns$`),
+		}, {
+			Name:       "usage",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "value",
+			MaxRSS:     1 << 30,
+			WantFail:   ptrTo("Usage error:\nresource usage not reported by the runner"),
+		},
+	}))
+
+	if runtime.GOOS != "linux" {
+		t.Skip("resource usage not supported")
+	}
+
+	name := os.Args[0]
+	Test(t, Program(name, []string{"GOLDEN_TEST_MOCK="}), ToCase([]FailCase{
+		{
+			Name:       "program",
+			Args:       []string{name, "stdout", "value"},
+			WantStdout: "value",
+			MaxRSS:     1 << 30,
+			MaxCPU:     time.Minute,
+		}, {
+			Name:       "memory",
+			Args:       []string{name, "stdout", "value"},
+			WantStdout: "value",
+			MaxRSS:     1024,
+			WantFail:   ptrTo(`^MaxRSS match error:\ngot: \d+ bytes \(\d+\.\d MiB\), want: at most 1024 bytes \(0\.0 MiB\)$`),
+		},
+	}))
+}
+
 func TestExternal(t *testing.T) {
 	Test(t, Program("go", nil), []Case{
 		// go version outputs
@@ -310,9 +1171,321 @@ func TestMock(t *testing.T) {
 	})
 }
 
+func TestExitSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals not supported")
+	}
+
+	name := os.Args[0]
+	Test(t, Program(name, []string{"GOLDEN_TEST_MOCK="}), ToCase([]FailCase{
+		{
+			Args:       []string{name, "kill"},
+			WantErr:    "signal: killed",
+			WantExit:   "-1",
+			WantSignal: "killed",
+		}, {
+			Args:         []string{name, "kill"},
+			WantErr:      "signal: killed",
+			WantSignal:   "killed",
+			WantFail:     ptrTo("WantExitCode match error:\ngot: -1 (signal: killed), want: 0"),
+			WantExitCode: 0,
+		},
+	}))
+}
+
+func TestSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals not supported")
+	}
+
+	name := os.Args[0]
+	Test(t, Program(name, []string{"GOLDEN_TEST_MOCK="}), []Case{
+		{
+			Args:        []string{name, "trap"},
+			Signal:      os.Interrupt,
+			SignalAfter: "ready",
+			WantStdout:  "ready\ncleanup: interrupt\n",
+			WantErr:     "exit status 130",
+			WantExit:    "128..255",
+		}, {
+			Args:        []string{name, "sleep"},
+			Signal:      syscall.SIGTERM,
+			SignalDelay: 10 * time.Millisecond,
+			WantErr:     "signal: terminated",
+			WantExit:    "-1",
+			WantSignal:  "terminated",
+		},
+	})
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Args:       []string{"echo", "stdout", "value"},
+			Signal:     os.Interrupt,
+			WantStdout: "value",
+			WantFail:   ptrTo("Signal error:\nrunner does not implement Signaler"),
+		},
+	}))
+}
+
+func TestStdin(t *testing.T) {
+	name := os.Args[0]
+	Test(t, Program(name, []string{"GOLDEN_TEST_MOCK="}), []Case{
+		{
+			Args:       []string{name, "repl"},
+			Stdin:      "a\nb\nquit\n",
+			WantStdout: "> A\n> B\n> bye\n",
+			WantExit:   "0",
+		}, {
+			Args:       []string{name, "repl"},
+			WantStdout: "> ",
+			WantErr:    "exit status 1",
+			WantExit:   "1",
+		},
+	})
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Args:       []string{"echo", "stdout", "value"},
+			Stdin:      "value",
+			WantStdout: "value",
+			WantFail:   ptrTo("Stdin error:\nrunner does not implement StdinSetter"),
+		},
+	}))
+}
+
+func TestSession(t *testing.T) {
+	name := os.Args[0]
+	s := NewSession(t, Program(name, []string{"GOLDEN_TEST_MOCK="}), []string{name, "repl"})
+
+	s.Expect("> $", time.Second)
+	s.Send("hello")
+	if got := s.Expect("[A-Z]+", time.Second); got != "HELLO" {
+		t.Fatalf("Expect error: got %q, want %q", got, "HELLO")
+	}
+	s.Expect("> $", time.Second)
+	s.Send("fail")
+	s.Expect("error: .*\n> $", time.Second)
+	s.Send("quit")
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Match("golden")
+}
+
+func TestSessionKill(t *testing.T) {
+	name := os.Args[0]
+	s := NewSession(t, Program(name, []string{"GOLDEN_TEST_MOCK="}), []string{name, "sleep"})
+	s.CloseTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	err := s.Close()
+	if got, want := fmt.Sprint(err), "signal: killed"; got != want {
+		t.Errorf("Close error: got %q, want %q", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Close error: got %v, want a bounded wait", elapsed)
+	}
+}
+
+func TestSessionFatal(t *testing.T) {
+	name := os.Args[0]
+	f := &failing{TB: t, name: t.Name()}
+	s := NewSession(f, Program(name, []string{"GOLDEN_TEST_MOCK="}), []string{name, "sleep"})
+	s.CloseTimeout = time.Minute
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Expect("never", 10*time.Millisecond)
+	}()
+	<-done
+
+	if len(f.errors) != 1 || !strings.HasPrefix(f.errors[0], "Expect timeout") {
+		t.Errorf("Expect error: got %q, want an Expect timeout", f.errors)
+	}
+
+	start := time.Now()
+	err := s.Close()
+	if got, want := fmt.Sprint(err), "signal: killed"; got != want {
+		t.Errorf("Close error: got %q, want %q", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Close error: got %v, want the command killed on failure", elapsed)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminal not supported")
+	}
+
+	name := os.Args[0]
+	env := []string{"GOLDEN_TEST_MOCK="}
+
+	Test(t, Program(name, env), []Case{
+		{
+			Args:       []string{name, "tty"},
+			WantStdout: "pipe\n...",
+			WantStderr: "...---\n",
+		},
+	})
+
+	Test(t, Terminal(name, env, Term{}), []Case{
+		{
+			Args:       []string{name, "tty"},
+			WantStdout: "terminal\r\n\x1b[31mred\x1b[0m\r\n...",
+		}, {
+			Args:       []string{name, "repl"},
+			Stdin:      "a\n",
+			WantStdout: "...A\r\n...",
+			WantExit:   "1",
+			WantErr:    "exit status 1",
+		}, {
+			Args:       []string{name, "repl"},
+			WantStdout: "> ",
+			WantExit:   "1",
+			WantErr:    "exit status 1",
+		},
+	})
+
+	Test(t, Terminal(name, env, Term{Rows: 6, Cols: 20, Screen: true}), []Case{
+		{
+			Args:       []string{name, "tty"},
+			WantStdout: "golden",
+		}, {
+			Args:       []string{name, "scroll"},
+			WantStdout: "next\n\n\n\n\nlast\n",
+		},
+	})
+}
+
+func TestReport(t *testing.T) {
+	report := "report.json"
+	defer TmpFiles(t, &report)()
+	dir := filepath.Join("testdata", "golden")
+	defer os.Remove(filepath.Join(dir, "TestReport-missing.actual"))
+	defer os.Remove(filepath.Join(dir, "TestReport-fail.WantStdout.got"))
+	defer os.Remove(filepath.Join(dir, "TestReport-fail.WantStdout.want"))
+	defer SetReport(report)()
+
+	f := &failing{TB: t, name: t.Name()}
+	Test(f, new(echo), []Case{
+		{
+			Name:       "pass",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "...lu...",
+		}, {
+			Name:       "fail",
+			Args:       []string{"echo", "stdout", "value\nnew"},
+			WantStdout: "value\nold",
+		}, {
+			Name:       "missing",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "golden",
+		}, {
+			Name:       "stdin",
+			Args:       []string{"echo", "stdout", "value"},
+			Stdin:      "input",
+			WantStdout: "value",
+		},
+	})
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "inner",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "other",
+			WantFail:   ptrTo("WantStdout match error:..."),
+		},
+	}))
+
+	if err := WriteReports(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Cases []struct {
+			Test   string
+			Pass   bool
+			Checks []struct {
+				Name, Matcher, Got, Diff string
+				Pass                     bool
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(got.Cases); n != 5 {
+		t.Fatalf("Report error: got %d cases, want 5", n)
+	}
+	if c := got.Cases[0]; c.Test != "TestReport/pass" || !c.Pass || c.Checks[0].Matcher != "substring" {
+		t.Errorf("Report error: unexpected pass case: %+v", c)
+	}
+	if c := got.Cases[1]; c.Test != "TestReport/fail" || c.Pass || c.Checks[0].Pass {
+		t.Errorf("Report error: unexpected fail case: %+v", c)
+	} else if want := "--- want\n+++ got\n@@ -1,2 +1,2 @@\n value\n-old\n+new\n"; c.Checks[0].Diff != want {
+		t.Errorf("Report error: got diff %q, want %q", c.Checks[0].Diff, want)
+	}
+	if c := got.Cases[2]; c.Pass || c.Checks[0].Name != "WantStdout" || c.Checks[0].Matcher != "golden" || c.Checks[0].Pass {
+		t.Errorf("Report error: unexpected missing case: %+v", c)
+	}
+	if c := got.Cases[3]; c.Pass || c.Checks[0].Name != "Stdin" || c.Checks[0].Got != "input" || c.Checks[0].Pass {
+		t.Errorf("Report error: unexpected stdin case: %+v", c)
+	}
+	if c := got.Cases[4]; c.Test != "TestReport/inner" || !c.Pass {
+		t.Errorf("Report error: unexpected inner case: %+v", c)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	dir := "report"
+	defer TmpFiles(t, &dir)()
+	defer os.Remove(filepath.Join("testdata", "golden", "TestHTML-fail.WantStdout.got"))
+	defer os.Remove(filepath.Join("testdata", "golden", "TestHTML-fail.WantStdout.want"))
+
+	defer SetHTML(dir)()
+
+	Test(&failing{TB: t, name: t.Name()}, new(echo), []Case{
+		{
+			Name:       "fail",
+			Args:       []string{"echo", "stdout", "<value>\nnew"},
+			WantStdout: "<value>\nold",
+		},
+	})
+
+	if err := WriteReports(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`class="fail">TestHTML/fail</a>`,
+		`<td>&lt;value&gt;</td>`,
+		`<td class="del">old</td>`,
+		`<td class="add">new</td>`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("HTML error: missing %s", want)
+		}
+	}
+}
+
 func TestErrors(t *testing.T) {
 	missing, file, dir := "missing", "file", "dir"
 	defer TmpFiles(t, &missing, &file, &dir)()
+	defer os.Remove(filepath.Join("testdata", "golden", "TestErrors-missing.ext.actual"))
 
 	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
 		t.Fatal("cannot write temporary file: " + file)
@@ -354,9 +1527,22 @@ func TestErrors(t *testing.T) {
 			Args:         []string{"echo", "exit"},
 			WantFail:     ptrTo("WantExitCode match error:\ngot: 4, want: 0"),
 			WantExitCode: 0,
+		}, {
+			Args:     []string{"echo", "exit"},
+			WantExit: "0..3",
+			WantFail: ptrTo("WantExit match error:\ngot: 4, want: 0..3"),
+		}, {
+			Args:     []string{"echo", "exit"},
+			WantExit: "4..0",
+			WantFail: ptrTo("WantExit syntax error:\ninvalid range: 4..0"),
+		}, {
+			Args:     []string{"echo", "exit"},
+			WantExit: "four",
+			WantFail: ptrTo("WantExit syntax error:\ninvalid exit code: four"),
 		},
 		// bad golden file
 		{
+			Name:         "missing",
 			Args:         []string{"echo", "stdout", "value"},
 			WantStdout:   "golden.ext",
 			WantFail:     ptrTo("WantStdout golden.ext read error:\n..."),
@@ -377,6 +1563,162 @@ func TestErrors(t *testing.T) {
 	}))
 }
 
+func TestActual(t *testing.T) {
+	defer flag.Set("golden-actual", flag.Lookup("golden-actual").Value.String())
+	flag.Set("golden-actual", "true")
+
+	dir := filepath.Join("testdata", "golden")
+	stale := filepath.Join(dir, "TestActual-pass.stdout.actual")
+	actual := filepath.Join(dir, "TestActual-fail.stdout.actual")
+	missing := filepath.Join(dir, "TestActual-missing.stdout.actual")
+	defer os.Remove(actual)
+	defer os.Remove(missing)
+
+	if err := ioutil.WriteFile(stale, nil, 0600); err != nil {
+		t.Fatal("cannot write stale file: " + stale)
+	}
+
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "pass",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "golden.stdout",
+		}, {
+			Name:       "fail",
+			Args:       []string{"echo", "stdout", "new"},
+			WantStdout: "golden.stdout",
+			WantFail:   ptrTo("WantStdout golden.stdout match error:..."),
+		}, {
+			Name:       "missing",
+			Args:       []string{"echo", "stdout", "new"},
+			WantStdout: "golden.stdout",
+			WantFail:   ptrTo("WantStdout golden.stdout read error:..."),
+		},
+	}))
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Actual error: stale file %s not removed", stale)
+	}
+
+	for _, name := range []string{actual, missing} {
+		if data, err := ioutil.ReadFile(name); err != nil || string(data) != "new" {
+			t.Errorf("Actual error: got %q, %v, want %q", data, err, "new")
+		}
+		os.Remove(name)
+	}
+
+	defer flag.Set("golden-got", flag.Lookup("golden-got").Value.String())
+	flag.Set("golden-actual", "false")
+	flag.Set("golden-got", "false")
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "disabled",
+			Args:       []string{"echo", "stdout", "new"},
+			WantStdout: "golden.stdout",
+			WantFail:   ptrTo("WantStdout golden.stdout read error:..."),
+		},
+	}))
+
+	disabled := filepath.Join(dir, "TestActual-disabled.stdout.actual")
+	if _, err := os.Stat(disabled); !os.IsNotExist(err) {
+		os.Remove(disabled)
+		t.Errorf("Actual error: file %s written without the actual flag", disabled)
+	}
+}
+
+// failing represents a test harness that records the errors of its subtests
+// without failing, to test the side effects of failed cases.
+type failing struct {
+	testing.TB
+	name   string   // test name
+	errors []string // recorded errors
+}
+
+func (f *failing) Name() string              { return f.name }
+func (f *failing) Error(args ...interface{}) { f.errors = append(f.errors, fmt.Sprint(args...)) }
+func (f *failing) FailNow()                  {}
+func (f *failing) Fatal(args ...interface{}) { f.Error(args...); runtime.Goexit() }
+func (f *failing) Run(name string, fn func(t testing.TB)) bool {
+	sub := &failing{TB: f.TB, name: f.name + "/" + name}
+	fn(sub)
+	f.errors = append(f.errors, sub.errors...)
+	return len(sub.errors) == 0
+}
+
+func TestGot(t *testing.T) {
+	defer flag.Set("golden-got", flag.Lookup("golden-got").Value.String())
+	flag.Set("golden-got", "true")
+
+	dir := filepath.Join("testdata", "golden")
+	stale := filepath.Join(dir, "TestGot-pass.WantStdout.got")
+	files := map[string]string{
+		filepath.Join(dir, "TestGot-literal.WantStdout.got"):  "new",
+		filepath.Join(dir, "TestGot-literal.WantStdout.want"): "old",
+		filepath.Join(dir, "TestGot-escaped.WantErr.got"):     "new",
+		filepath.Join(dir, "TestGot-escaped.WantErr.want"):    "=old",
+		filepath.Join(dir, "TestGot-golden.stdout.actual"):    "new",
+	}
+	for name := range files {
+		defer os.Remove(name)
+	}
+
+	if err := ioutil.WriteFile(stale, nil, 0600); err != nil {
+		t.Fatal("cannot write stale file: " + stale)
+	}
+
+	f := &failing{TB: t, name: t.Name()}
+	Test(f, new(echo), []Case{
+		{
+			Name:       "pass",
+			Args:       []string{"echo", "stdout", "value"},
+			WantStdout: "value",
+		}, {
+			Name:       "literal",
+			Args:       []string{"echo", "stdout", "new"},
+			WantStdout: "old",
+		}, {
+			Name:         "escaped",
+			Args:         []string{"echo", "err", "new"},
+			WantErr:      "==old",
+			WantExitCode: 3,
+		}, {
+			Name:       "golden",
+			Args:       []string{"echo", "stdout", "new"},
+			WantStdout: "golden.stdout",
+		},
+	})
+	if len(f.errors) != 3 {
+		t.Errorf("Got error: got %d errors, want 3:\n%s", len(f.errors), strings.Join(f.errors, "\n"))
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Got error: stale file %s not removed", stale)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "TestGot-golden.stdout.got")); !os.IsNotExist(err) {
+		t.Errorf("Got error: unexpected got file of a gold master")
+	}
+
+	for name, want := range files {
+		if data, err := ioutil.ReadFile(name); err != nil || string(data) != want {
+			t.Errorf("Got error: %s: got %q, %v, want %q", name, data, err, want)
+		}
+	}
+
+	// expected failures of the inner testing write no got files
+	Test(t, new(echo), ToCase([]FailCase{
+		{
+			Name:       "inner",
+			Args:       []string{"echo", "stdout", "new"},
+			WantStdout: "old",
+			WantFail:   ptrTo("WantStdout match error:..."),
+		},
+	}))
+	if _, err := os.Stat(filepath.Join(dir, "TestGot-inner.WantStdout.got")); !os.IsNotExist(err) {
+		t.Errorf("Got error: unexpected got file of an expected failure")
+	}
+}
+
 func TestFormat(t *testing.T) {
 	Test(t, new(echo), ToCase([]FailCase{
 		// single-line error format with one empty string
@@ -427,6 +1769,23 @@ func TestFormat(t *testing.T) {
 			WantFail:     ptrTo("WantStdout match error:\ngot:\n    value\n    value\nwant:\n    value"),
 			WantExitCode: 0,
 		},
+		// binary error format
+		{
+			Args:       []string{"echo", "stdout", "\x00\x01"},
+			WantStdout: "\x00\x02",
+			WantFail: ptrTo(`^WantStdout match error \(binary\):
+got: 2 bytes, sha256 [0-9a-f]{64}
+want: 2 bytes, sha256 [0-9a-f]{64}
+first difference at offset 1 \(0x1\)
+got:
+    00000000  00 01 {44} \|\.\.\|
+want:
+    00000000  00 02 {44} \|\.\.\|$`),
+		}, {
+			Args:       []string{"echo", "stdout"},
+			WantStdout: "\xff\xff",
+			WantFail:   ptrTo("...first difference at offset 0 (0x0)\ngot:\n    (end of data)\nwant:\n    00000000  ff ff..."),
+		},
 		// smart string error format
 		{
 			Args:         []string{"echo", "stdout"},
@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/larhun/golden/internal/diff"
+)
+
+// row represents a row of a side-by-side difference. An empty operation
+// represents a missing line.
+type row struct {
+	WantOp, Want string
+	GotOp, Got   string
+}
+
+// sideBySide returns the rows of the side-by-side difference between the want
+// and got strings.
+func sideBySide(want, got string) []row {
+	var rows []row
+	var removed, added []string
+
+	flush := func() {
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			var r row
+			if i < len(removed) {
+				r.WantOp, r.Want = "-", removed[i]
+			}
+			if i < len(added) {
+				r.GotOp, r.Got = "+", added[i]
+			}
+			rows = append(rows, r)
+		}
+		removed, added = nil, nil
+	}
+
+	for _, l := range diff.Lines(want, got) {
+		switch l.Op {
+		case '-':
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, l.Text)
+		case '+':
+			added = append(added, l.Text)
+		default:
+			flush()
+			rows = append(rows, row{" ", l.Text, " ", l.Text})
+		}
+	}
+	flush()
+
+	return rows
+}
+
+// acceptCommand returns the command that updates the gold master of the named
+// test, matching the test and all its parents exactly.
+func acceptCommand(test string) string {
+	parts := strings.Split(test, "/")
+	for i, part := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(part) + "$"
+	}
+	return "go test -run '" + strings.Join(parts, "/") + "' -update"
+}
+
+// hasCheck reports whether the message is the error message of a check.
+func hasCheck(checks []check, message string) bool {
+	for _, c := range checks {
+		if c.Message == message {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlTemplate holds the template of the HTML report.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sideBySide": sideBySide,
+	"accept":     acceptCommand,
+	"hasCheck":   hasCheck,
+	"isBinary":   isBinary,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Golden report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+.pass { color: #2a7d2a; }
+.fail { color: #b22; }
+table.diff { border-collapse: collapse; width: 100%; table-layout: fixed; }
+table.diff td { font-family: monospace; white-space: pre-wrap; word-break: break-all; vertical-align: top; padding: 0 .4em; }
+table.diff td.op { width: 1em; color: #888; }
+td.del { background: #fdd; }
+td.add { background: #dfd; }
+pre, code { background: #f4f4f4; padding: .2em .4em; }
+</style>
+</head>
+<body>
+<h1>Golden report</h1>
+<ul>
+{{- range $i, $c := .}}
+<li><a href="#case{{$i}}" class="{{if $c.Pass}}pass{{else}}fail{{end}}">{{$c.Test}}</a> {{if $c.Pass}}pass{{else}}fail{{end}}</li>
+{{- end}}
+</ul>
+{{- range $i, $c := .}}{{if not $c.Pass}}
+<h2 id="case{{$i}}" class="fail">{{$c.Test}}</h2>
+<p>Arguments: <code>{{printf "%q" $c.Args}}</code></p>
+{{- range $c.Messages}}{{if not (hasCheck $c.Checks .)}}
+<pre>{{.}}</pre>
+{{- end}}{{end}}
+{{- range $c.Checks}}{{if not .Pass}}
+<h3>{{.Name}} <small>({{.Matcher}} match)</small></h3>
+{{- if .File}}
+<p>Gold master: <code>{{.File}}</code><br>Accept: <code>{{accept $c.Test}}</code></p>
+{{- end}}
+{{- if or (isBinary .Want) (isBinary .Got)}}
+<pre>{{.Message}}</pre>
+{{- else}}
+<table class="diff">
+<tr><th></th><th>want</th><th></th><th>got</th></tr>
+{{- range sideBySide .Want .Got}}
+<tr><td class="op">{{.WantOp}}</td><td{{if eq .WantOp "-"}} class="del"{{end}}>{{.Want}}</td><td class="op">{{.GotOp}}</td><td{{if eq .GotOp "+"}} class="add"{{end}}>{{.Got}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}{{end}}
+{{- end}}{{end}}
+</body>
+</html>
+`))
+
+// writeHTML writes the HTML report of the test cases to the index.html file
+// in the named directory.
+func writeHTML(dir string, cases []caseReport) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, cases); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, "index.html"), b.Bytes(), 0600)
+}
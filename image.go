@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	_ "image/gif"  // register the GIF format
+	_ "image/jpeg" // register the JPEG format
+)
+
+// imageExts holds the extensions of the supported image formats.
+var imageExts = []string{".png", ".jpg", ".jpeg", ".gif"}
+
+// isImage reports whether the named file is a supported image.
+func isImage(name string) bool {
+	for _, ext := range imageExts {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareImages returns the number of pixels of the got image that differ from
+// the want image by more than the tolerance in any 8-bit channel, and an image
+// that highlights the differing pixels in red over a faded copy of the want
+// image. The images must have the same size.
+func compareImages(got, want image.Image, tolerance uint8) (int, image.Image) {
+	b := want.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	offset := got.Bounds().Min.Sub(b.Min)
+
+	differ := func(x, y uint32) bool {
+		x, y = x>>8, y>>8
+		return x > y && x-y > uint32(tolerance) || y > x && y-x > uint32(tolerance)
+	}
+
+	n := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, a1 := got.At(x+offset.X, y+offset.Y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+
+			if differ(r1, r2) || differ(g1, g2) || differ(b1, b2) || differ(a1, a2) {
+				n++
+				out.Set(x-b.Min.X, y-b.Min.Y, color.RGBA{255, 0, 0, 255})
+				continue
+			}
+
+			gray := color.GrayModel.Convert(want.At(x, y)).(color.Gray).Y
+			fade := 255 - (255-gray)/4
+			out.Set(x-b.Min.X, y-b.Min.Y, color.RGBA{fade, fade, fade, 255})
+		}
+	}
+
+	return n, out
+}
+
+// matchImage tests if the got image data matches the gold master image with
+// the specified extension. Pixels match if no 8-bit channel differs by more
+// than the tolerance, and the images match if the ratio of differing pixels
+// does not exceed the maximum ratio. If not, accumulates an error and writes
+// the difference image next to the gold master.
+func (m *match) matchImage(data, ext string, tolerance uint8, maxRatio float64) {
+	name := "File golden" + ext
+
+	want, ok := m.getGolden(name, ext, data)
+	if !ok {
+		return // file error
+	}
+
+	c := check{
+		Name:    "File",
+		Matcher: "image",
+		Got:     data,
+		Want:    want,
+		File:    m.goldenFile(ext),
+	}
+	diffFile := c.File + ".diff.png"
+
+	gotImage, _, err := image.Decode(strings.NewReader(data))
+	if err != nil {
+		m.messages = append(m.messages, "File image decode error:\n"+err.Error())
+		return
+	}
+
+	wantImage, _, err := image.Decode(strings.NewReader(want))
+	if err != nil {
+		m.messages = append(m.messages, name+" decode error:\n"+err.Error())
+		return
+	}
+
+	gb, wb := gotImage.Bounds(), wantImage.Bounds()
+	if gb.Dx() != wb.Dx() || gb.Dy() != wb.Dy() {
+		c.Message = fmt.Sprintf("%s match error:\ngot: %dx%d image, want: %dx%d image",
+			name, gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+		m.setActual(name, c.File, data, false)
+		m.addCheck(c)
+		return
+	}
+
+	n, diff := compareImages(gotImage, wantImage, tolerance)
+	total := wb.Dx() * wb.Dy()
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(n) / float64(total)
+	}
+
+	if c.Pass = n == 0 || ratio <= maxRatio; c.Pass {
+		if err := os.Remove(diffFile); err != nil && !os.IsNotExist(err) {
+			m.messages = append(m.messages, name+" remove error:\n"+err.Error())
+		}
+	} else {
+		c.Message = fmt.Sprintf("%s match error:\n%d of %d pixels differ (%.2f%%), want at most %.2f%%\ndifference image: %s",
+			name, n, total, 100*ratio, 100*maxRatio, diffFile)
+
+		var b bytes.Buffer
+		err := png.Encode(&b, diff)
+		if err == nil {
+			err = ioutil.WriteFile(diffFile, b.Bytes(), 0600)
+		}
+		if err != nil {
+			m.messages = append(m.messages, name+" write error:\n"+err.Error())
+		}
+	}
+
+	m.setActual(name, c.File, data, c.Pass)
+	m.addCheck(c)
+}
@@ -6,19 +6,46 @@
 
 package golden
 
+import (
+	"os"
+	"time"
+)
+
 // Functions required to test the error printed by a test that should fail.
 
 // FailCase is a Case with an exported WantFail field.
 type FailCase struct {
-	Name         string
-	Args         []string
-	WantFile     string
-	WantStdout   string
-	WantStderr   string
-	WantPanic    string
-	WantErr      string
-	WantFail     *string // exported field
-	WantExitCode int
+	Name           string
+	Args           []string
+	Stdin          string
+	WantFile       string
+	FileModes      bool
+	PixelTolerance uint8
+	MaxPixelRatio  float64
+	IgnoreColumns  []string
+	KeyColumn      string
+	AbsTolerance   float64
+	RelTolerance   float64
+	Unordered      bool
+	Vars           map[string]interface{}
+	WantStdout     string
+	WantStderr     string
+	WantJSON       map[string]string
+	WantLogs       []string
+	LogSubset      bool
+	Normalize      []Normalizer
+	WantPanic      string
+	WantErr        string
+	WantFail       *string // exported field
+	WantExitCode   int
+	WantExit       string
+	Signal         os.Signal
+	SignalDelay    time.Duration
+	SignalAfter    string
+	WantSignal     string
+	MaxDuration    time.Duration
+	MaxRSS         int64
+	MaxCPU         time.Duration
 }
 
 // ToCase return a list of test cases.
@@ -26,27 +53,79 @@ func ToCase(failCases []FailCase) []Case {
 	testCases := make([]Case, len(failCases))
 	for i, fc := range failCases {
 		testCases[i] = Case{
-			Name:         fc.Name,
-			Args:         fc.Args,
-			WantFile:     fc.WantFile,
-			WantStdout:   fc.WantStdout,
-			WantStderr:   fc.WantStderr,
-			WantPanic:    fc.WantPanic,
-			WantErr:      fc.WantErr,
-			wantFail:     fc.WantFail, // set non exported field
-			WantExitCode: fc.WantExitCode,
+			Name:           fc.Name,
+			Args:           fc.Args,
+			Stdin:          fc.Stdin,
+			WantFile:       fc.WantFile,
+			FileModes:      fc.FileModes,
+			PixelTolerance: fc.PixelTolerance,
+			MaxPixelRatio:  fc.MaxPixelRatio,
+			IgnoreColumns:  fc.IgnoreColumns,
+			KeyColumn:      fc.KeyColumn,
+			AbsTolerance:   fc.AbsTolerance,
+			RelTolerance:   fc.RelTolerance,
+			Unordered:      fc.Unordered,
+			Vars:           fc.Vars,
+			WantStdout:     fc.WantStdout,
+			WantStderr:     fc.WantStderr,
+			WantJSON:       fc.WantJSON,
+			WantLogs:       fc.WantLogs,
+			LogSubset:      fc.LogSubset,
+			Normalize:      fc.Normalize,
+			WantPanic:      fc.WantPanic,
+			WantErr:        fc.WantErr,
+			wantFail:       fc.WantFail, // set non exported field
+			WantExitCode:   fc.WantExitCode,
+			WantExit:       fc.WantExit,
+			Signal:         fc.Signal,
+			SignalDelay:    fc.SignalDelay,
+			SignalAfter:    fc.SignalAfter,
+			WantSignal:     fc.WantSignal,
+			MaxDuration:    fc.MaxDuration,
+			MaxRSS:         fc.MaxRSS,
+			MaxCPU:         fc.MaxCPU,
 		}
 	}
 	return testCases
 }
 
+// SetReport sets the JSON report file, with an empty report collector, and
+// returns a function restoring the previous report file and collector.
+func SetReport(file string) func() {
+	saved, savedFile := reports, *reportFile
+	reports, *reportFile = new(reportCollector), file
+	return func() {
+		reports, *reportFile = saved, savedFile
+	}
+}
+
+// WriteReports writes the enabled reports of the test cases.
+func WriteReports() error {
+	return writeReports()
+}
+
+// SetHTML sets the HTML report directory, with an empty report collector, and
+// returns a function restoring the previous report directory and collector.
+func SetHTML(dir string) func() {
+	saved, savedDir := reports, *htmlDir
+	reports, *htmlDir = new(reportCollector), dir
+	return func() {
+		reports, *htmlDir = saved, savedDir
+	}
+}
+
+// SetWantFail sets the expected error of the recorder.
+func (r *Recorder) SetWantFail(wantFail *string) {
+	r.wantFail = wantFail
+}
+
 // Error overrides the embedded Error method to mock a test failure without
 // actually failing when wantFail is equal to "test".
 func (m *match) Error(args ...interface{}) {
 	if m.wantFail != nil && *m.wantFail == "test" {
 		return
 	}
-	m.T.Error(args...)
+	m.TB.Error(args...)
 }
 
 // FailNow overrides the embedded FailNow method to mock a test failure without
@@ -56,5 +135,5 @@ func (m *match) FailNow() {
 		*m.wantFail = "ok"
 		return
 	}
-	m.T.FailNow()
+	m.TB.FailNow()
 }
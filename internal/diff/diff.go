@@ -0,0 +1,197 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+// Package diff provides functions for computing line differences.
+package diff
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxCells holds the maximum size of the table used to compute the longest
+// common subsequence. Larger inputs are reported as fully replaced.
+const maxCells = 4 << 20
+
+// Line represents a line of a difference. The operation is ' ' for a common
+// line, '-' for a removed line and '+' for an added line.
+type Line struct {
+	Op   byte
+	Text string
+}
+
+// split returns the lines of the string. An ending newline is ignored.
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Lines returns the line differences that transform a into b.
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	// skip the common prefix and suffix
+	i := 0
+	for i < len(x) && i < len(y) && x[i] == y[i] {
+		i++
+	}
+	j := 0
+	for j < len(x)-i && j < len(y)-i && x[len(x)-1-j] == y[len(y)-1-j] {
+		j++
+	}
+
+	lines := make([]Line, 0, len(x)+len(y))
+	for _, s := range x[:i] {
+		lines = append(lines, Line{' ', s})
+	}
+	lines = append(lines, middle(x[i:len(x)-j], y[i:len(y)-j])...)
+	for _, s := range x[len(x)-j:] {
+		lines = append(lines, Line{' ', s})
+	}
+	return lines
+}
+
+// middle returns the line differences that transform x into y using the
+// longest common subsequence.
+func middle(x, y []string) []Line {
+	var lines []Line
+
+	n, m := len(x), len(y)
+	if n*m > maxCells {
+		for _, s := range x {
+			lines = append(lines, Line{'-', s})
+		}
+		for _, s := range y {
+			lines = append(lines, Line{'+', s})
+		}
+		return lines
+	}
+
+	// lcs[i][j] holds the length of the longest common subsequence of x[i:]
+	// and y[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && x[i] == y[j]:
+			lines = append(lines, Line{' ', x[i]})
+			i++
+			j++
+		case j == m || i < n && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{'-', x[i]})
+			i++
+		default:
+			lines = append(lines, Line{'+', y[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+// Unified returns the differences that transform a into b in the unified
+// format with the specified file names and three lines of context. It returns
+// an empty string if a and b have the same lines.
+func Unified(nameA, nameB, a, b string) string {
+	lines := Lines(a, b)
+
+	var out strings.Builder
+	const context = 3
+
+	for i := 0; i < len(lines); {
+		// find the next change
+		for i < len(lines) && lines[i].Op == ' ' {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+
+		// extend the hunk while changes are close
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].Op != ' ' {
+				end++
+				continue
+			}
+			k := end
+			for k < len(lines) && lines[k].Op == ' ' {
+				k++
+			}
+			if k == len(lines) || k-end > 2*context {
+				break
+			}
+			end = k
+		}
+		stop := end + context
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		if out.Len() == 0 {
+			out.WriteString("--- " + nameA + "\n+++ " + nameB + "\n")
+		}
+		out.WriteString(header(lines, start, stop))
+		for _, l := range lines[start:stop] {
+			out.WriteByte(l.Op)
+			out.WriteString(l.Text)
+			out.WriteByte('\n')
+		}
+		i = stop
+	}
+
+	return out.String()
+}
+
+// header returns the header of the hunk of lines from start to stop.
+func header(lines []Line, start, stop int) string {
+	a, b := 1, 1
+	for _, l := range lines[:start] {
+		if l.Op != '+' {
+			a++
+		}
+		if l.Op != '-' {
+			b++
+		}
+	}
+	na, nb := 0, 0
+	for _, l := range lines[start:stop] {
+		if l.Op != '+' {
+			na++
+		}
+		if l.Op != '-' {
+			nb++
+		}
+	}
+	if na == 0 {
+		a--
+	}
+	if nb == 0 {
+		b--
+	}
+	return "@@ -" + strconv.Itoa(a) + "," + strconv.Itoa(na) +
+		" +" + strconv.Itoa(b) + "," + strconv.Itoa(nb) + " @@\n"
+}
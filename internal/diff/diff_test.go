@@ -0,0 +1,30 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, want string
+	}{
+		{"", "", ""},
+		{"a\nb\n", "a\nb", ""},
+		{"a", "", "--- want\n+++ got\n@@ -1,1 +0,0 @@\n-a\n"},
+		{"", "a", "--- want\n+++ got\n@@ -0,0 +1,1 @@\n+a\n"},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12",
+			"1\n2\n3\nfour\n5\n6\n7\n8\n9\n10\n11\n12\n13",
+			"--- want\n+++ got\n" +
+				"@@ -1,7 +1,7 @@\n 1\n 2\n 3\n-4\n+four\n 5\n 6\n 7\n" +
+				"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n",
+		},
+	} {
+		if got := Unified("want", "got", tc.a, tc.b); got != tc.want {
+			t.Errorf("Unified(%q, %q):\ngot:\n%s\nwant:\n%s", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,670 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+// Package toml implements a parser and a formatter for TOML documents.
+//
+// A parsed document is a tree of map[string]interface{}, []interface{},
+// string, int64, float64, bool and Datetime values.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Datetime represents an offset or local date-time, date or time, as written
+// with an upper case "T" separator.
+type Datetime string
+
+// parser represents the state of a document parser.
+type parser struct {
+	s string // parsed text
+	i int    // current index
+}
+
+// Parse parses the TOML document.
+func Parse(data string) (map[string]interface{}, error) {
+	p := &parser{s: strings.Replace(data, "\r\n", "\n", -1)}
+	root := map[string]interface{}{}
+	table := root
+
+	for {
+		p.blank()
+		if p.i == len(p.s) {
+			return root, nil
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(p.s[p.i:], "[["):
+			table, err = p.arrayTable(root)
+		case p.s[p.i] == '[':
+			table, err = p.table(root)
+		default:
+			err = p.keyValue(table)
+		}
+		if err == nil {
+			err = p.endLine()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", strings.Count(p.s[:p.i], "\n")+1, err)
+		}
+	}
+}
+
+// blank skips whitespace, newlines and comments.
+func (p *parser) blank() {
+	for p.i < len(p.s) {
+		switch p.s[p.i] {
+		case ' ', '\t', '\n':
+			p.i++
+		case '#':
+			p.comment()
+		default:
+			return
+		}
+	}
+}
+
+// space skips whitespace.
+func (p *parser) space() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// comment skips a comment, if any.
+func (p *parser) comment() {
+	if p.i < len(p.s) && p.s[p.i] == '#' {
+		for p.i < len(p.s) && p.s[p.i] != '\n' {
+			p.i++
+		}
+	}
+}
+
+// endLine skips the end of the current line.
+func (p *parser) endLine() error {
+	p.space()
+	p.comment()
+	if p.i < len(p.s) && p.s[p.i] != '\n' {
+		return fmt.Errorf("expected a newline, found: %c", p.s[p.i])
+	}
+	return nil
+}
+
+// expect skips the expected string.
+func (p *parser) expect(s string) error {
+	p.space()
+	if !strings.HasPrefix(p.s[p.i:], s) {
+		return fmt.Errorf("expected %s", s)
+	}
+	p.i += len(s)
+	return nil
+}
+
+// table parses a table header and returns the table.
+func (p *parser) table(root map[string]interface{}) (map[string]interface{}, error) {
+	p.i++ // skip [
+	keys, err := p.keys()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return navigate(root, keys)
+}
+
+// arrayTable parses an array of tables header, appends a new table to the
+// array and returns the table.
+func (p *parser) arrayTable(root map[string]interface{}) (map[string]interface{}, error) {
+	p.i += 2 // skip [[
+	keys, err := p.keys()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("]]"); err != nil {
+		return nil, err
+	}
+
+	parent, err := navigate(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys[len(keys)-1]
+	var list []interface{}
+	if v, ok := parent[key]; ok {
+		if list, ok = v.([]interface{}); !ok || !isTableArray(list) {
+			return nil, fmt.Errorf("key %s is not an array of tables", key)
+		}
+	}
+
+	table := map[string]interface{}{}
+	parent[key] = append(list, table)
+	return table, nil
+}
+
+// navigate returns the table with the specified dotted keys, creating the
+// missing tables. The last table of an array of tables is used.
+func navigate(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		v, ok := table[key]
+		if !ok {
+			v = map[string]interface{}{}
+			table[key] = v
+		}
+
+		switch v := v.(type) {
+		case map[string]interface{}:
+			table = v
+		case []interface{}:
+			if !isTableArray(v) {
+				return nil, fmt.Errorf("key %s is not a table", key)
+			}
+			table = v[len(v)-1].(map[string]interface{})
+		default:
+			return nil, fmt.Errorf("key %s is not a table", key)
+		}
+	}
+	return table, nil
+}
+
+// keyValue parses a key/value pair and adds it to the table.
+func (p *parser) keyValue(table map[string]interface{}) error {
+	keys, err := p.keys()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	p.space()
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	if table, err = navigate(table, keys[:len(keys)-1]); err != nil {
+		return err
+	}
+	key := keys[len(keys)-1]
+	if _, ok := table[key]; ok {
+		return fmt.Errorf("duplicate key: %s", key)
+	}
+	table[key] = v
+	return nil
+}
+
+// keys parses a dotted key.
+func (p *parser) keys() ([]string, error) {
+	var keys []string
+	for {
+		p.space()
+		if p.i == len(p.s) {
+			return nil, fmt.Errorf("expected a key")
+		}
+
+		var key string
+		var err error
+		switch p.s[p.i] {
+		case '"':
+			key, err = p.basic()
+		case '\'':
+			key, err = p.literal()
+		default:
+			start := p.i
+			for p.i < len(p.s) && isBare(p.s[p.i]) {
+				p.i++
+			}
+			if key = p.s[start:p.i]; key == "" {
+				err = fmt.Errorf("expected a key, found: %c", p.s[p.i])
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		if p.space(); p.i == len(p.s) || p.s[p.i] != '.' {
+			return keys, nil
+		}
+		p.i++
+	}
+}
+
+// isBare reports whether the character is allowed in a bare key.
+func isBare(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '_' || c == '-'
+}
+
+// value parses a value.
+func (p *parser) value() (interface{}, error) {
+	if p.i == len(p.s) {
+		return nil, fmt.Errorf("expected a value")
+	}
+
+	switch p.s[p.i] {
+	case '"':
+		return p.basic()
+	case '\'':
+		return p.literal()
+	case '[':
+		return p.array()
+	case '{':
+		return p.inlineTable()
+	}
+
+	for _, b := range []bool{true, false} {
+		if s := strconv.FormatBool(b); strings.HasPrefix(p.s[p.i:], s) {
+			p.i += len(s)
+			return b, nil
+		}
+	}
+
+	start := p.i
+	for p.i < len(p.s) && (isBare(p.s[p.i]) || strings.IndexByte("+.:", p.s[p.i]) >= 0) {
+		p.i++
+		// a date followed by a time separated by a space
+		if p.i-start == 10 && datePattern.MatchString(p.s[start:p.i]) &&
+			p.i+1 < len(p.s) && p.s[p.i] == ' ' && '0' <= p.s[p.i+1] && p.s[p.i+1] <= '9' {
+			p.i++
+		}
+	}
+	token := p.s[start:p.i]
+	if v := number(token); v != nil {
+		return v, nil
+	}
+	return nil, fmt.Errorf("invalid value: %s", token)
+}
+
+var (
+	datePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	datetimePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?([Zz]|[-+]\d{2}:\d{2})?)?|\d{2}:\d{2}(:\d{2}(\.\d+)?)?)$`)
+	intPattern      = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)$`)
+	floatPattern    = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][-+]?[0-9](_?[0-9])*)?$`)
+)
+
+// number returns the number or datetime represented by the token, or nil.
+func number(token string) interface{} {
+	switch token {
+	case "inf", "+inf":
+		return math.Inf(1)
+	case "-inf":
+		return math.Inf(-1)
+	case "nan", "+nan", "-nan":
+		return math.NaN()
+	}
+
+	if datetimePattern.MatchString(token) {
+		if len(token) > 10 && token[4] == '-' {
+			token = token[:10] + "T" + token[11:]
+		}
+		return Datetime(strings.Replace(token, "z", "Z", 1))
+	}
+
+	digits := strings.Replace(token, "_", "", -1)
+	for prefix, base := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if strings.HasPrefix(token, prefix) {
+			if len(digits) == 2 || strings.ContainsAny(digits[2:], "+-") {
+				return nil
+			}
+			if i, err := strconv.ParseInt(digits[2:], base, 64); err == nil {
+				return i
+			}
+			return nil
+		}
+	}
+
+	switch {
+	case intPattern.MatchString(token):
+		if i, err := strconv.ParseInt(digits, 10, 64); err == nil {
+			return i
+		}
+	case floatPattern.MatchString(token):
+		if f, err := strconv.ParseFloat(digits, 64); err == nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// basic parses a basic or multi-line basic string.
+func (p *parser) basic() (string, error) {
+	multi := strings.HasPrefix(p.s[p.i:], `"""`)
+	if multi {
+		p.i += 3
+		p.newline()
+	} else {
+		p.i++
+	}
+
+	var b strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		switch {
+		case c == '"' && !multi:
+			p.i++
+			return b.String(), nil
+		case c == '"' && strings.HasPrefix(p.s[p.i:], `"""`):
+			p.i += 3
+			for n := 0; n < 2 && p.i < len(p.s) && p.s[p.i] == '"'; n++ {
+				b.WriteByte('"') // quotes before the delimiter
+				p.i++
+			}
+			return b.String(), nil
+		case c == '\n' && !multi:
+			return "", fmt.Errorf("unterminated string")
+		case c == '\\':
+			if err := p.escape(&b, multi); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.i++
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// escapes holds the single character escape sequences of basic strings.
+var escapes = map[byte]string{
+	'b': "\b", 't': "\t", 'n': "\n", 'f': "\f", 'r': "\r", 'e': "\x1b", '"': "\"", '\\': "\\",
+}
+
+// escape parses the escape sequence starting at the current backslash.
+func (p *parser) escape(b *strings.Builder, multi bool) error {
+	p.i++
+	if p.i == len(p.s) {
+		return fmt.Errorf("unterminated string")
+	}
+
+	c := p.s[p.i]
+	if e, ok := escapes[c]; ok {
+		b.WriteString(e)
+		p.i++
+		return nil
+	}
+
+	if multi && (c == ' ' || c == '\t' || c == '\n') {
+		// line ending backslash
+		rest := strings.TrimLeft(p.s[p.i:], " \t")
+		if rest == "" || rest[0] != '\n' {
+			return fmt.Errorf("invalid escape sequence: \\%c", c)
+		}
+		p.i = len(p.s) - len(strings.TrimLeft(rest, " \t\n"))
+		return nil
+	}
+
+	n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if n == 0 || p.i+n >= len(p.s) {
+		return fmt.Errorf("invalid escape sequence: \\%c", c)
+	}
+	r, err := strconv.ParseUint(p.s[p.i+1:p.i+1+n], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(r)) {
+		return fmt.Errorf("invalid escape sequence: \\%s", p.s[p.i:p.i+1+n])
+	}
+	b.WriteRune(rune(r))
+	p.i += n + 1
+	return nil
+}
+
+// literal parses a literal or multi-line literal string.
+func (p *parser) literal() (string, error) {
+	if strings.HasPrefix(p.s[p.i:], "'''") {
+		p.i += 3
+		p.newline()
+		n := strings.Index(p.s[p.i:], "'''")
+		if n < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		for extra := 0; extra < 2 && p.i+n+3 < len(p.s) && p.s[p.i+n+3] == '\''; extra++ {
+			n++ // quotes before the delimiter
+		}
+		s := p.s[p.i : p.i+n]
+		p.i += n + 3
+		return s, nil
+	}
+
+	p.i++
+	n := strings.IndexAny(p.s[p.i:], "'\n")
+	if n < 0 || p.s[p.i+n] != '\'' {
+		return "", fmt.Errorf("unterminated string")
+	}
+	s := p.s[p.i : p.i+n]
+	p.i += n + 1
+	return s, nil
+}
+
+// newline skips a newline immediately following the opening delimiter of a
+// multi-line string.
+func (p *parser) newline() {
+	if p.i < len(p.s) && p.s[p.i] == '\n' {
+		p.i++
+	}
+}
+
+// array parses an array.
+func (p *parser) array() (interface{}, error) {
+	list := []interface{}{}
+	p.i++ // skip [
+
+	for {
+		p.blank()
+		if p.i < len(p.s) && p.s[p.i] == ']' {
+			p.i++
+			return list, nil
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+
+		p.blank()
+		switch {
+		case p.i == len(p.s):
+			return nil, fmt.Errorf("unterminated array")
+		case p.s[p.i] == ',':
+			p.i++
+		case p.s[p.i] != ']':
+			return nil, fmt.Errorf("expected , or ] in array, found: %c", p.s[p.i])
+		}
+	}
+}
+
+// inlineTable parses an inline table.
+func (p *parser) inlineTable() (interface{}, error) {
+	table := map[string]interface{}{}
+	p.i++ // skip {
+
+	if p.space(); p.i < len(p.s) && p.s[p.i] == '}' {
+		p.i++
+		return table, nil
+	}
+
+	for {
+		if err := p.keyValue(table); err != nil {
+			return nil, err
+		}
+
+		p.space()
+		switch {
+		case p.i == len(p.s):
+			return nil, fmt.Errorf("unterminated inline table")
+		case p.s[p.i] == ',':
+			p.i++
+		case p.s[p.i] == '}':
+			p.i++
+			return table, nil
+		default:
+			return nil, fmt.Errorf("expected , or } in inline table, found: %c", p.s[p.i])
+		}
+	}
+}
+
+// isTableArray reports whether the list is a non-empty array of tables.
+func isTableArray(list []interface{}) bool {
+	for _, v := range list {
+		if _, ok := v.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// Format returns the canonical form of the document: keys sorted, values
+// listed before sub-tables, and each table and array of tables written as a
+// section with its full dotted key.
+func Format(table map[string]interface{}) string {
+	var b strings.Builder
+	writeTable(&b, nil, table)
+	return b.String()
+}
+
+// writeTable writes the content of the table with the specified dotted key.
+func writeTable(b *strings.Builder, path []string, table map[string]interface{}) {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sections []string
+	for _, k := range keys {
+		switch v := table[k].(type) {
+		case map[string]interface{}:
+			sections = append(sections, k)
+			continue
+		case []interface{}:
+			if isTableArray(v) {
+				sections = append(sections, k)
+				continue
+			}
+		}
+		b.WriteString(Key(k) + " = " + Value(table[k]) + "\n")
+	}
+
+	for _, k := range sections {
+		sub := append(append([]string(nil), path...), k)
+		header := make([]string, len(sub))
+		for i, s := range sub {
+			header[i] = Key(s)
+		}
+
+		if v, ok := table[k].(map[string]interface{}); ok {
+			writeHeader(b, "["+strings.Join(header, ".")+"]")
+			writeTable(b, sub, v)
+			continue
+		}
+		for _, v := range table[k].([]interface{}) {
+			writeHeader(b, "[["+strings.Join(header, ".")+"]]")
+			writeTable(b, sub, v.(map[string]interface{}))
+		}
+	}
+}
+
+// writeHeader writes a section header, preceded by an empty line if not first.
+func writeHeader(b *strings.Builder, header string) {
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteString(header + "\n")
+}
+
+// Key returns the key, quoted if not bare.
+func Key(k string) string {
+	for i := 0; i < len(k); i++ {
+		if !isBare(k[i]) {
+			return quote(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
+
+// Value returns the inline form of the value.
+func Value(v interface{}) string {
+	switch v := v.(type) {
+
+	case string:
+		return quote(v)
+
+	case int64:
+		return strconv.FormatInt(v, 10)
+
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return "inf"
+		case math.IsInf(v, -1):
+			return "-inf"
+		case math.IsNaN(v):
+			return "nan"
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+
+	case bool:
+		return strconv.FormatBool(v)
+
+	case Datetime:
+		return string(v)
+
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = Value(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = Key(k) + " = " + Value(v[k])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+
+	return fmt.Sprint(v)
+}
+
+// quote returns the string as a basic string.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteString(`\` + string(r))
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
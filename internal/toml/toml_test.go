@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package toml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	text := `# config
+title = "demo"   # comment
+"quoted key" = 'C:\path'
+site.name = "example"
+version = 1.5
+count = 0x1_0
+when = 1979-05-27 07:32:00Z
+
+[server]
+ports = [ 80,
+  443, # https
+]
+motd = """
+Hello \
+  World"""
+raw = '''
+it's'''
+
+[[users]]
+name = "a"
+roles = { admin = true }
+
+[[users]]
+name = "b"
+
+[users.extra]
+level = -2
+`
+	want := map[string]interface{}{
+		"title":      "demo",
+		"quoted key": `C:\path`,
+		"site":       map[string]interface{}{"name": "example"},
+		"version":    1.5,
+		"count":      int64(16),
+		"when":       Datetime("1979-05-27T07:32:00Z"),
+		"server": map[string]interface{}{
+			"ports": []interface{}{int64(80), int64(443)},
+			"motd":  "Hello World",
+			"raw":   "it's",
+		},
+		"users": []interface{}{
+			map[string]interface{}{"name": "a", "roles": map[string]interface{}{"admin": true}},
+			map[string]interface{}{"name": "b", "extra": map[string]interface{}{"level": int64(-2)}},
+		},
+	}
+
+	got, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse error:\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	canonical := `count = 16
+"quoted key" = "C:\\path"
+title = "demo"
+version = 1.5
+when = 1979-05-27T07:32:00Z
+
+[server]
+motd = "Hello World"
+ports = [80, 443]
+raw = "it's"
+
+[site]
+name = "example"
+
+[[users]]
+name = "a"
+
+[users.roles]
+admin = true
+
+[[users]]
+name = "b"
+
+[users.extra]
+level = -2
+`
+	if got := Format(got); got != canonical {
+		t.Fatalf("Format error:\ngot:\n%s\nwant:\n%s", got, canonical)
+	}
+
+	again, err := Parse(canonical)
+	if err != nil || !reflect.DeepEqual(again, want) {
+		t.Fatalf("Parse canonical error: %v\ngot:  %#v\nwant: %#v", err, again, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for text, want := range map[string]string{
+		"a = 1\na = 2":        "line 2: duplicate key: a",
+		"a = 1 b = 2":         "line 1: expected a newline, found: b",
+		"a = \"x":             "line 1: unterminated string",
+		"a = [1, 2":           "line 1: unterminated array",
+		"a = 1\n[a]":          "line 2: key a is not a table",
+		"a = 1\n[[a]]":        "line 2: key a is not an array of tables",
+		"a = value":           "line 1: invalid value: value",
+		"[a\nb = 1":           "line 1: expected ]",
+		"a = { b = 1, b = 2}": "line 1: duplicate key: b",
+	} {
+		_, err := Parse(text)
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("Parse(%q) error: got %v, want %s", text, err, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+// Package txtar implements a trivial text-based file archive format.
+//
+// An archive is a comment followed by a sequence of files. Each file starts
+// with a "-- name --" marker line and holds the following lines up to the next
+// marker line or the end of the archive:
+//
+//	comment
+//	-- hello.txt --
+//	Hello World!
+//	-- empty.txt --
+package txtar
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Archive represents a text archive.
+type Archive struct {
+	Comment string
+	Files   []File
+}
+
+// File represents a file of a text archive.
+type File struct {
+	Name string // file name
+	Data string // file content
+}
+
+// Format returns the text of the archive. A newline is added to the comment
+// and to the file contents that do not end with one.
+func Format(a *Archive) string {
+	var b bytes.Buffer
+	b.WriteString(fixNewline(a.Comment))
+	for _, f := range a.Files {
+		b.WriteString("-- " + f.Name + " --\n")
+		b.WriteString(fixNewline(f.Data))
+	}
+	return b.String()
+}
+
+// Parse returns the archive of the text.
+func Parse(text string) *Archive {
+	a := new(Archive)
+
+	var name string
+	var data strings.Builder
+	inFile := false
+
+	flush := func() {
+		if inFile {
+			a.Files = append(a.Files, File{name, data.String()})
+		} else {
+			a.Comment = data.String()
+		}
+		data.Reset()
+	}
+
+	for len(text) > 0 {
+		line := text
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			line, text = text[:i+1], text[i+1:]
+		} else {
+			text = ""
+		}
+
+		if n, ok := marker(line); ok {
+			flush()
+			name, inFile = n, true
+			continue
+		}
+		data.WriteString(line)
+	}
+	flush()
+
+	return a
+}
+
+// marker returns the file name of a marker line and reports whether the line is
+// a marker line.
+func marker(line string) (string, bool) {
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if !strings.HasPrefix(line, "-- ") || !strings.HasSuffix(line, " --") || len(line) < 7 {
+		return "", false
+	}
+	name := strings.TrimSpace(line[3 : len(line)-3])
+	return name, name != ""
+}
+
+// fixNewline returns the string with an ending newline added if missing.
+func fixNewline(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package txtar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	text := "comment\n-- a.txt --\nline 1\nline 2\n-- empty --\n-- b/c.txt --\nlast"
+	want := &Archive{
+		Comment: "comment\n",
+		Files: []File{
+			{"a.txt", "line 1\nline 2\n"},
+			{"empty", ""},
+			{"b/c.txt", "last"},
+		},
+	}
+
+	got := Parse(text)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse error:\ngot:  %+v\nwant: %+v", got, want)
+	}
+
+	if got, want := Format(got), text+"\n"; got != want {
+		t.Fatalf("Format error:\ngot:  %q\nwant: %q", got, want)
+	}
+}
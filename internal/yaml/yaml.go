@@ -0,0 +1,777 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+// Package yaml implements a parser and a formatter for the subset of YAML used
+// by configuration files: a single document of block mappings and sequences,
+// flow collections, plain and quoted scalars, and literal and folded block
+// scalars. Anchors, aliases, tags and multi-line flow scalars are not
+// supported.
+//
+// A parsed document is a tree of map[string]interface{}, []interface{},
+// string, int64, float64, bool and nil values.
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// line represents a significant line of a document.
+type line struct {
+	num    int    // line number, starting from 1
+	indent int    // indentation
+	text   string // content without indentation and comment
+}
+
+// parser represents the state of a document parser.
+type parser struct {
+	raw   []string // all the lines, used by block scalars
+	lines []line   // significant lines
+	pos   int      // current significant line
+}
+
+// Parse parses the YAML document.
+func Parse(data string) (interface{}, error) {
+	p := &parser{raw: strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n")}
+
+lines:
+	for i, s := range p.raw {
+		text := strings.TrimRight(stripComment(s), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		switch {
+		case trimmed == "":
+		case trimmed[0] == '\t':
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		case text == "---":
+			if len(p.lines) > 0 {
+				return nil, fmt.Errorf("line %d: multiple documents are not supported", i+1)
+			}
+		case text == "...":
+			break lines // document end
+		case text[0] == '%':
+			// directive
+		default:
+			p.lines = append(p.lines, line{i + 1, len(text) - len(trimmed), trimmed})
+		}
+	}
+
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	v, err := p.node(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf(p.lines[p.pos], "unexpected content")
+	}
+	return v, nil
+}
+
+// errorf returns an error reporting the line number.
+func (p *parser) errorf(l line, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", l.num, fmt.Sprintf(format, args...))
+}
+
+// node parses the node starting at the current line with the specified
+// indentation.
+func (p *parser) node(indent int) (interface{}, error) {
+	l := p.lines[p.pos]
+	switch {
+	case isItem(l.text):
+		return p.sequence(indent)
+	case keyEnd(l.text) >= 0:
+		return p.mapping(indent)
+	}
+
+	p.pos++
+	return p.value(l, l.text)
+}
+
+// child parses the node nested in a parent node with the specified
+// indentation, if any.
+func (p *parser) child(indent int) (interface{}, error) {
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return p.node(p.lines[p.pos].indent)
+	}
+	return nil, nil
+}
+
+// isItem reports whether the text starts a sequence item.
+func isItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// sequence parses a block sequence with the specified indentation.
+func (p *parser) sequence(indent int) (interface{}, error) {
+	list := []interface{}{}
+
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent || l.indent == indent && !isItem(l.text) {
+			break
+		}
+		if l.indent > indent || !isItem(l.text) {
+			return nil, p.errorf(l, "bad indentation of a sequence item")
+		}
+
+		var v interface{}
+		var err error
+		rest := strings.TrimLeft(l.text[1:], " ")
+		switch {
+		case rest == "":
+			p.pos++
+			v, err = p.child(indent)
+		case rest[0] == '|' || rest[0] == '>':
+			p.pos++
+			v, err = p.block(l, indent, rest)
+		default:
+			// parse the item content as a node with a deeper indentation
+			p.lines[p.pos] = line{l.num, indent + len(l.text) - len(rest), rest}
+			v, err = p.node(p.lines[p.pos].indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+
+	return list, nil
+}
+
+// mapping parses a block mapping with the specified indentation.
+func (p *parser) mapping(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		i := keyEnd(l.text)
+		if l.indent > indent || i < 0 {
+			return nil, p.errorf(l, "bad indentation of a mapping entry")
+		}
+
+		key, err := p.key(l, strings.TrimRight(l.text[:i], " "))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, p.errorf(l, "duplicate key: %s", key)
+		}
+
+		var v interface{}
+		rest := strings.TrimLeft(l.text[i+1:], " ")
+		p.pos++
+		switch {
+		case rest == "":
+			if p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isItem(p.lines[p.pos].text) {
+				v, err = p.sequence(indent) // compact sequence
+			} else {
+				v, err = p.child(indent)
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			v, err = p.block(l, indent, rest)
+		default:
+			v, err = p.value(l, rest)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+
+	return m, nil
+}
+
+// key returns the mapping key represented by the text.
+func (p *parser) key(l line, text string) (string, error) {
+	if text == "" || text[0] != '"' && text[0] != '\'' {
+		return text, nil
+	}
+	s := &scanner{s: text}
+	key, err := s.quoted()
+	if err == nil && s.i < len(text) {
+		err = fmt.Errorf("unexpected characters after key")
+	}
+	if err != nil {
+		return "", p.errorf(l, "%v", err)
+	}
+	return key, nil
+}
+
+// value parses the flow value represented by the text of the line, joined to
+// the following lines until all the flow collections are closed.
+func (p *parser) value(l line, text string) (interface{}, error) {
+	for !balanced(text) && p.pos < len(p.lines) {
+		text += " " + p.lines[p.pos].text
+		p.pos++
+	}
+
+	s := &scanner{s: text}
+	v, err := s.value(false)
+	if err == nil {
+		if s.space(); s.i < len(text) {
+			err = fmt.Errorf("unexpected characters: %s", text[s.i:])
+		}
+	}
+	if err != nil {
+		return nil, p.errorf(l, "%v", err)
+	}
+	return v, nil
+}
+
+// block parses the block scalar with the specified header, nested in a parent
+// node with the specified indentation and starting after the line.
+func (p *parser) block(l line, indent int, header string) (interface{}, error) {
+	folded := header[0] == '>'
+	chomp := byte(0)
+	width := 0
+	for _, c := range header[1:] {
+		switch {
+		case c == '-' || c == '+':
+			chomp = byte(c)
+		case c >= '1' && c <= '9':
+			width = indent + int(c-'0')
+		default:
+			return nil, p.errorf(l, "invalid block scalar header: %s", header)
+		}
+	}
+
+	var lines []string
+	end := l.num // index of the first raw line after the block
+	for ; end < len(p.raw); end++ {
+		s := p.raw[end]
+		n := len(s) - len(strings.TrimLeft(s, " "))
+		if strings.TrimSpace(s) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if n <= indent {
+			break
+		}
+		if width == 0 {
+			width = n
+		}
+		if n < width {
+			return nil, p.errorf(line{num: end + 1}, "bad indentation of a block scalar")
+		}
+		lines = append(lines, s[width:])
+	}
+
+	for p.pos < len(p.lines) && p.lines[p.pos].num <= end {
+		p.pos++
+	}
+
+	// split trailing empty lines
+	n := len(lines)
+	for n > 0 && lines[n-1] == "" {
+		n--
+	}
+	trailing := len(lines) - n
+	lines = lines[:n]
+
+	var b strings.Builder
+	for i, s := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case !folded || prev != "" && prev[0] == ' ' || s != "" && s[0] == ' ':
+				b.WriteByte('\n')
+			case prev != "" && s != "":
+				b.WriteByte(' ')
+			case prev != "":
+				// folded into the following empty line
+			default:
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(s)
+	}
+
+	switch {
+	case chomp == '-' || b.Len() == 0 && chomp != '+':
+	case chomp == '+':
+		b.WriteString(strings.Repeat("\n", trailing+1))
+	default:
+		b.WriteByte('\n')
+	}
+
+	return b.String(), nil
+}
+
+// stripComment returns the line without its comment.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" \t[{,", s[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#':
+			if i == 0 || s[i-1] == ' ' || s[i-1] == '\t' {
+				return s[:i]
+			}
+		}
+	}
+	return s
+}
+
+// keyEnd returns the index of the colon ending the mapping key at the start of
+// the text, or -1 if the text does not start with a mapping key.
+func keyEnd(s string) int {
+	i := 0
+	if s != "" {
+		switch s[0] {
+		case '"', '\'':
+			sc := &scanner{s: s}
+			if _, err := sc.quoted(); err != nil {
+				return -1
+			}
+			i = sc.i
+		case '[', '{':
+			return -1
+		}
+	}
+
+	for ; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			return i
+		}
+	}
+	return -1
+}
+
+// balanced reports whether all the flow collections opened by the text are
+// closed.
+func balanced(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [{,:", s[i-1]) >= 0):
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// scanner represents the state of a flow value scanner.
+type scanner struct {
+	s string // scanned text
+	i int    // current index
+}
+
+// space skips spaces.
+func (s *scanner) space() {
+	for s.i < len(s.s) && (s.s[s.i] == ' ' || s.s[s.i] == '\t') {
+		s.i++
+	}
+}
+
+// value scans a flow value. Within a flow collection, plain scalars end at
+// flow indicators.
+func (s *scanner) value(inFlow bool) (interface{}, error) {
+	s.space()
+	if s.i == len(s.s) {
+		return nil, nil
+	}
+
+	switch s.s[s.i] {
+	case '[':
+		return s.list()
+	case '{':
+		return s.mapping()
+	case '"', '\'':
+		return s.quoted()
+	case '&', '*', '!':
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported")
+	case '|', '>':
+		return nil, fmt.Errorf("unexpected block scalar")
+	}
+
+	return resolve(s.plain(inFlow)), nil
+}
+
+// plain scans a plain scalar.
+func (s *scanner) plain(inFlow bool) string {
+	start := s.i
+	for ; s.i < len(s.s); s.i++ {
+		c := s.s[s.i]
+		if inFlow && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+		if inFlow && c == ':' && (s.i+1 == len(s.s) || strings.IndexByte(" ,]}", s.s[s.i+1]) >= 0) {
+			break
+		}
+	}
+	return strings.TrimSpace(s.s[start:s.i])
+}
+
+// list scans a flow sequence.
+func (s *scanner) list() (interface{}, error) {
+	list := []interface{}{}
+	s.i++ // skip [
+
+	for {
+		s.space()
+		if s.i < len(s.s) && s.s[s.i] == ']' {
+			s.i++
+			return list, nil
+		}
+
+		v, err := s.value(true)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+
+		if err := s.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// mapping scans a flow mapping.
+func (s *scanner) mapping() (interface{}, error) {
+	m := map[string]interface{}{}
+	s.i++ // skip {
+
+	for {
+		s.space()
+		if s.i < len(s.s) && s.s[s.i] == '}' {
+			s.i++
+			return m, nil
+		}
+
+		var key string
+		var err error
+		if s.i < len(s.s) && (s.s[s.i] == '"' || s.s[s.i] == '\'') {
+			key, err = s.quoted()
+		} else {
+			key = s.plain(true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("duplicate key: %s", key)
+		}
+
+		var v interface{}
+		if s.space(); s.i < len(s.s) && s.s[s.i] == ':' {
+			s.i++
+			if v, err = s.value(true); err != nil {
+				return nil, err
+			}
+		}
+		m[key] = v
+
+		if err := s.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator scans the separator following an item of a flow collection with
+// the specified closing indicator.
+func (s *scanner) separator(end byte) error {
+	s.space()
+	switch {
+	case s.i == len(s.s):
+		return fmt.Errorf("unterminated flow collection")
+	case s.s[s.i] == ',':
+		s.i++
+	case s.s[s.i] == end:
+	default:
+		return fmt.Errorf("unexpected character in flow collection: %c", s.s[s.i])
+	}
+	return nil
+}
+
+// quoted scans a single or double quoted scalar.
+func (s *scanner) quoted() (string, error) {
+	quote := s.s[s.i]
+	var b strings.Builder
+
+	for s.i++; s.i < len(s.s); s.i++ {
+		c := s.s[s.i]
+		switch {
+		case c == quote && quote == '\'' && s.i+1 < len(s.s) && s.s[s.i+1] == '\'':
+			b.WriteByte('\'')
+			s.i++
+		case c == quote:
+			s.i++
+			return b.String(), nil
+		case c == '\\' && quote == '"':
+			if err := s.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", fmt.Errorf("unterminated quoted scalar")
+}
+
+// escapes holds the single character escape sequences of double quoted
+// scalars.
+var escapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+	'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"",
+	'/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028",
+	'P': "\u2029",
+}
+
+// escape scans the escape sequence starting at the current backslash.
+func (s *scanner) escape(b *strings.Builder) error {
+	s.i++
+	if s.i == len(s.s) {
+		return fmt.Errorf("unterminated escape sequence")
+	}
+
+	c := s.s[s.i]
+	if e, ok := escapes[c]; ok {
+		b.WriteString(e)
+		return nil
+	}
+
+	n := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+	if n == 0 || s.i+n >= len(s.s) {
+		return fmt.Errorf("invalid escape sequence: \\%c", c)
+	}
+	r, err := strconv.ParseUint(s.s[s.i+1:s.i+1+n], 16, 32)
+	if err != nil {
+		return fmt.Errorf("invalid escape sequence: \\%s", s.s[s.i:s.i+1+n])
+	}
+	b.WriteRune(rune(r))
+	s.i += n
+	return nil
+}
+
+var (
+	intPattern   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	floatPattern = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// resolve returns the value of the plain scalar according to the YAML core
+// schema.
+func resolve(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return math.Inf(1)
+	case "-.inf", "-.Inf", "-.INF":
+		return math.Inf(-1)
+	case ".nan", ".NaN", ".NAN":
+		return math.NaN()
+	}
+
+	switch {
+	case intPattern.MatchString(s):
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case strings.HasPrefix(s, "0x"):
+		if i, err := strconv.ParseInt(s[2:], 16, 64); err == nil {
+			return i
+		}
+	case strings.HasPrefix(s, "0o"):
+		if i, err := strconv.ParseInt(s[2:], 8, 64); err == nil {
+			return i
+		}
+	}
+
+	if floatPattern.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	return s
+}
+
+// Format returns the canonical form of the value: block collections indented
+// by two spaces, mapping keys sorted, and scalars quoted only if needed.
+func Format(v interface{}) string {
+	var b strings.Builder
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			writeMapping(&b, v, 0)
+			return b.String()
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			writeSequence(&b, v, 0)
+			return b.String()
+		}
+	}
+	return Scalar(v) + "\n"
+}
+
+// writeMapping writes the non-empty mapping with the specified indentation.
+func writeMapping(b *strings.Builder, m map[string]interface{}, indent int) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteString(quote(k))
+		b.WriteByte(':')
+		writeChild(b, m[k], indent+2, "\n")
+	}
+}
+
+// writeSequence writes the non-empty sequence with the specified indentation.
+func writeSequence(b *strings.Builder, list []interface{}, indent int) {
+	for _, v := range list {
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteByte('-')
+		writeChild(b, v, indent+2, " ")
+	}
+}
+
+// writeChild writes the value nested in a collection. Non-empty collections
+// are written with the specified indentation, after the separator.
+func writeChild(b *strings.Builder, v interface{}, indent int, sep string) {
+	var nested strings.Builder
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			writeMapping(&nested, v, indent)
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			writeSequence(&nested, v, indent)
+		}
+	}
+
+	if nested.Len() == 0 {
+		b.WriteString(" " + Scalar(v) + "\n")
+		return
+	}
+	s := nested.String()
+	if sep == " " {
+		s = s[indent:] // compact form
+	}
+	b.WriteString(sep + s)
+}
+
+// Scalar returns the inline form of the value. Collections are written in the
+// flow style.
+func Scalar(v interface{}) string {
+	switch v := v.(type) {
+
+	case nil:
+		return "null"
+
+	case bool:
+		return strconv.FormatBool(v)
+
+	case int64:
+		return strconv.FormatInt(v, 10)
+
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return ".inf"
+		case math.IsInf(v, -1):
+			return "-.inf"
+		case math.IsNaN(v):
+			return ".nan"
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+
+	case string:
+		return quote(v)
+
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = Scalar(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = quote(k) + ": " + Scalar(v[k])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+
+	return fmt.Sprint(v)
+}
+
+// quote returns the string as a plain scalar if it represents itself, or as a
+// double quoted scalar.
+func quote(s string) string {
+	plain := s != "" && utf8.ValidString(s) &&
+		strings.IndexByte("-?:,[]{}#&*!|>'\"%@` \t", s[0]) < 0 &&
+		!strings.ContainsAny(s, ",[]{}") &&
+		!strings.Contains(s, ": ") && !strings.Contains(s, " #") &&
+		!strings.HasSuffix(s, ":") && !strings.HasSuffix(s, " ")
+	if plain {
+		if _, ok := resolve(s).(string); !ok {
+			plain = false
+		}
+	}
+	for _, r := range s {
+		if !plain {
+			break
+		}
+		plain = strconv.IsPrint(r)
+	}
+
+	if plain {
+		return s
+	}
+	return strconv.Quote(s)
+}
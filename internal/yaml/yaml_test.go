@@ -0,0 +1,151 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	text := `# config
+---
+name: demo   # comment
+"quoted key": 'it''s'
+version: 1.5
+count: 0x10
+enabled: yes
+missing: ~
+servers:
+- host: a.example.com
+  ports: [80, 443]
+- host: "b\texample"
+  tags: {env: prod, "tier": 1}
+list:
+  - - x
+    - y
+  -
+  - "# not a comment"
+literal: |
+  line 1
+    line 2
+
+folded: >-
+  one
+  two
+
+  three
+empty: {}
+multi: [a,
+  b]
+`
+	want := map[string]interface{}{
+		"name":       "demo",
+		"quoted key": "it's",
+		"version":    1.5,
+		"count":      int64(16),
+		"enabled":    "yes",
+		"missing":    nil,
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a.example.com", "ports": []interface{}{int64(80), int64(443)}},
+			map[string]interface{}{"host": "b\texample", "tags": map[string]interface{}{"env": "prod", "tier": int64(1)}},
+		},
+		"list": []interface{}{
+			[]interface{}{"x", "y"},
+			nil,
+			"# not a comment",
+		},
+		"literal": "line 1\n  line 2\n",
+		"folded":  "one two\nthree",
+		"empty":   map[string]interface{}{},
+		"multi":   []interface{}{"a", "b"},
+	}
+
+	got, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parse error:\ngot:  %#v\nwant: %#v", got, want)
+	}
+
+	canonical := `count: 16
+empty: {}
+enabled: yes
+folded: "one two\nthree"
+list:
+  - - x
+    - y
+  - null
+  - "# not a comment"
+literal: "line 1\n  line 2\n"
+missing: null
+multi:
+  - a
+  - b
+name: demo
+quoted key: it's
+servers:
+  - host: a.example.com
+    ports:
+      - 80
+      - 443
+  - host: "b\texample"
+    tags:
+      env: prod
+      tier: 1
+version: 1.5
+`
+	if got := Format(got); got != canonical {
+		t.Fatalf("Format error:\ngot:\n%s\nwant:\n%s", got, canonical)
+	}
+
+	again, err := Parse(canonical)
+	if err != nil || !reflect.DeepEqual(again, want) {
+		t.Fatalf("Parse canonical error: %v\ngot:  %#v\nwant: %#v", err, again, want)
+	}
+}
+
+func TestParseScalars(t *testing.T) {
+	for text, want := range map[string]interface{}{
+		"":                     nil,
+		"value":                "value",
+		"-12":                  int64(-12),
+		"1e3":                  1000.0,
+		"true":                 true,
+		"'1'":                  "1",
+		"[]":                   []interface{}{},
+		"- a\n- b":             []interface{}{"a", "b"},
+		"a: b: c":              map[string]interface{}{"a": "b: c"},
+		"url: http://host:80/": map[string]interface{}{"url": "http://host:80/"},
+	} {
+		got, err := Parse(text)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", text, err)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse(%q) error:\ngot:  %#v\nwant: %#v", text, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for text, want := range map[string]string{
+		"a: 1\na: 2":      "line 2: duplicate key: a",
+		"a: 1\n  b: 2":    "line 2: bad indentation of a mapping entry",
+		"a: &x 1":         "line 1: anchors, aliases and tags are not supported",
+		"a: \"x":          "line 1: unterminated quoted scalar",
+		"a: [1, 2":        "line 1: unterminated flow collection",
+		"a: 1\n---\nb: 2": "line 2: multiple documents are not supported",
+		"- a\nb: 1":       "line 2: unexpected content",
+		"a:\n\t- b":       "line 2: tabs are not allowed in indentation",
+	} {
+		_, err := Parse(text)
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("Parse(%q) error: got %v, want %s", text, err, want)
+		}
+	}
+}
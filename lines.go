@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// splitLines returns the lines of the string. An ending newline is ignored.
+func splitLines(s string) []string {
+	if s = strings.TrimSuffix(s, "\n"); s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// sortLines returns the string with its lines sorted.
+func sortLines(s string) string {
+	lines := splitLines(s)
+	sort.Strings(lines)
+	sorted := strings.Join(lines, "\n")
+	if strings.HasSuffix(s, "\n") {
+		sorted += "\n"
+	}
+	return sorted
+}
+
+// compareLines returns the differences between the got and want strings
+// compared as multisets of lines: the wanted lines not found, in want order,
+// and the lines not wanted, in got order.
+func compareLines(got, want string) []string {
+	var problems []string
+
+	count := map[string]int{}
+	for _, line := range splitLines(got) {
+		count[line]++
+	}
+	for _, line := range splitLines(want) {
+		if count[line] == 0 {
+			problems = append(problems, "missing line: "+line)
+			continue
+		}
+		count[line]--
+	}
+
+	for _, line := range splitLines(got) {
+		if count[line] > 0 {
+			problems = append(problems, "unexpected line: "+line)
+			count[line]--
+		}
+	}
+
+	return problems
+}
+
+// matchLines tests if the got string matches the want string, which is either
+// a literal string or "golden" with an optional extension, for a gold master,
+// regardless of the order of the lines. If not, accumulates an
+// error with the specified name. The gold master is updated with the got lines
+// sorted.
+func (m *match) matchLines(name, got, want string) {
+	c := check{Name: name, Matcher: "unordered", Got: got, Want: want}
+
+	if ext := filepath.Ext(want); want == "golden"+ext {
+		c.File = m.goldenFile(ext)
+		name += " golden" + ext
+
+		var ok bool
+		if want, ok = m.getGolden(name, ext, sortLines(got)); !ok {
+			return // file error
+		}
+		c.Want = want
+	} else {
+		name += " unordered"
+	}
+
+	problems := compareLines(got, want)
+	if c.Pass = len(problems) == 0; !c.Pass {
+		c.Message = name + " match error:\n" + strings.Join(problems, "\n")
+	}
+
+	if c.File != "" {
+		m.setActual(name, c.File, sortLines(got), c.Pass)
+	}
+	m.addCheck(c)
+}
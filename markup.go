@@ -0,0 +1,325 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// xmlFormat and htmlFormat hold the markup formats.
+var (
+	xmlFormat  = markupFormat("xml", false)
+	htmlFormat = markupFormat("html", true)
+)
+
+// markupFormat returns the markup format with the specified name, parsed as
+// HTML if html is true.
+func markupFormat(name string, html bool) treeFormat {
+	return treeFormat{
+		name: name,
+		parse: func(s string) (interface{}, error) {
+			return parseMarkup(s, html)
+		},
+		format: func(v interface{}) string {
+			return formatMarkup(v.(*node))
+		},
+		compare: func(got, want interface{}) []string {
+			if d := compareMarkup("", got.(*node), want.(*node)); d != "" {
+				return []string{d}
+			}
+			return nil
+		},
+	}
+}
+
+// xmlURL holds the namespace bound to the reserved xml prefix.
+const xmlURL = "http://www.w3.org/XML/1998/namespace"
+
+// node represents an element, or a text node if the name is empty, of a markup
+// document. The document itself is represented by a root node with an empty
+// name and no text.
+type node struct {
+	name     xml.Name   // element name with resolved namespace
+	attrs    []xml.Attr // sorted attributes, namespace declarations excluded
+	children []*node    // child nodes
+	text     string     // text with normalized whitespace
+	pre      bool       // preformatted element, with whitespace preserved
+}
+
+// parseMarkup parses the XML document, or the HTML document if html is true.
+// Comments, processing instructions and directives are ignored, and the
+// whitespace of each text node is normalized, except inside the HTML pre and
+// textarea elements. The names of HTML elements and attributes are lower cased.
+func parseMarkup(data string, html bool) (*node, error) {
+	d := xml.NewDecoder(strings.NewReader(data))
+	if html {
+		d.Strict = false
+		d.AutoClose = xml.HTMLAutoClose
+		d.Entity = xml.HTMLEntity
+	}
+
+	root := new(node)
+	stack := []*node{root}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+
+		case xml.StartElement:
+			n := &node{name: t.Name}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Space == "" && a.Name.Local == "xmlns" {
+					continue // namespace declaration
+				}
+				n.attrs = append(n.attrs, a)
+			}
+			if html {
+				n.name.Local = strings.ToLower(n.name.Local)
+				for i := range n.attrs {
+					n.attrs[i].Name.Local = strings.ToLower(n.attrs[i].Name.Local)
+				}
+			}
+			sort.Slice(n.attrs, func(i, j int) bool { return lessName(n.attrs[i].Name, n.attrs[j].Name) })
+
+			top.children = append(top.children, n)
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+
+		case xml.CharData:
+			if last := len(top.children) - 1; last >= 0 && top.children[last].name.Local == "" {
+				top.children[last].text += string(t) // split by a comment
+			} else {
+				top.children = append(top.children, &node{text: string(t)})
+			}
+		}
+	}
+
+	root.normalize(html)
+	return root, nil
+}
+
+// preformatted holds the HTML elements whose whitespace is preserved.
+var preformatted = map[string]bool{"pre": true, "textarea": true}
+
+// normalize normalizes the whitespace of the text nodes and removes the empty
+// ones, except inside the preformatted elements of an HTML document.
+func (n *node) normalize(html bool) {
+	children := n.children[:0]
+	for _, c := range n.children {
+		switch {
+		case c.name.Local != "":
+			c.pre = n.pre || html && preformatted[c.name.Local]
+			c.normalize(html)
+		case n.pre:
+			// whitespace preserved
+		default:
+			if c.text = strings.Join(strings.Fields(c.text), " "); c.text == "" {
+				continue
+			}
+		}
+		children = append(children, c)
+	}
+	n.children = children
+}
+
+// lessName reports whether the name a sorts before the name b.
+func lessName(a, b xml.Name) bool {
+	if a.Space != b.Space {
+		return a.Space < b.Space
+	}
+	return a.Local < b.Local
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;",
+		"\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+)
+
+// formatMarkup returns the canonical form of the document: one node per line
+// indented by two spaces, elements with only one text node and preformatted
+// elements on one line, sorted attributes and generated namespace prefixes.
+func formatMarkup(root *node) string {
+	var b strings.Builder
+	for _, c := range root.children {
+		c.write(&b, "", 0)
+	}
+	return b.String()
+}
+
+// write writes the node at the specified depth, in a parent element with the
+// specified namespace.
+func (n *node) write(b *strings.Builder, space string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if n.name.Local == "" {
+		b.WriteString(indent + textEscaper.Replace(n.text) + "\n")
+		return
+	}
+
+	b.WriteString(indent)
+	n.writeTag(b, space)
+
+	switch {
+	case len(n.children) == 0:
+		b.WriteString("/>\n")
+	case n.pre || len(n.children) == 1 && n.children[0].name.Local == "":
+		b.WriteString(">")
+		for _, c := range n.children {
+			c.writeInline(b, n.name.Space)
+		}
+		b.WriteString("</" + n.name.Local + ">\n")
+	default:
+		b.WriteString(">\n")
+		for _, c := range n.children {
+			c.write(b, n.name.Space, depth+1)
+		}
+		b.WriteString(indent + "</" + n.name.Local + ">\n")
+	}
+}
+
+// writeInline writes the node without indentation and newlines, in a parent
+// element with the specified namespace.
+func (n *node) writeInline(b *strings.Builder, space string) {
+	if n.name.Local == "" {
+		b.WriteString(textEscaper.Replace(n.text))
+		return
+	}
+
+	n.writeTag(b, space)
+	if len(n.children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">")
+	for _, c := range n.children {
+		c.writeInline(b, n.name.Space)
+	}
+	b.WriteString("</" + n.name.Local + ">")
+}
+
+// writeTag writes the start tag of the element, without the closing bracket,
+// in a parent element with the specified namespace.
+func (n *node) writeTag(b *strings.Builder, space string) {
+	b.WriteString("<" + n.name.Local)
+	if n.name.Space != space {
+		b.WriteString(` xmlns="` + attrEscaper.Replace(n.name.Space) + `"`)
+	}
+
+	prefixes := map[string]string{xmlURL: "xml"}
+	for _, a := range n.attrs {
+		if _, ok := prefixes[a.Name.Space]; !ok && a.Name.Space != "" {
+			prefixes[a.Name.Space] = fmt.Sprintf("ns%d", len(prefixes))
+			fmt.Fprintf(b, ` xmlns:%s="%s"`, prefixes[a.Name.Space], attrEscaper.Replace(a.Name.Space))
+		}
+	}
+	for _, a := range n.attrs {
+		b.WriteString(" ")
+		if a.Name.Space != "" {
+			b.WriteString(prefixes[a.Name.Space] + ":")
+		}
+		b.WriteString(a.Name.Local + `="` + attrEscaper.Replace(a.Value) + `"`)
+	}
+}
+
+// attrName returns the name of the attribute as written in an XPath.
+func attrName(name xml.Name) string {
+	if name.Space == xmlURL {
+		return "xml:" + name.Local
+	}
+	return name.Local
+}
+
+// describe returns a description of the node.
+func (n *node) describe() string {
+	switch {
+	case n.name.Local == "":
+		return fmt.Sprintf("text %q", n.text)
+	case n.name.Space != "":
+		return "element <{" + n.name.Space + "}" + n.name.Local + ">"
+	}
+	return "element <" + n.name.Local + ">"
+}
+
+// childPaths returns the XPath of each child of the node with the specified
+// XPath. Positions are added to the children sharing the name with others.
+func (n *node) childPaths(path string) []string {
+	step := func(c *node) string {
+		if c.name.Local == "" {
+			return "text()"
+		}
+		return c.name.Local
+	}
+
+	count := map[xml.Name]int{}
+	for _, c := range n.children {
+		count[c.name]++
+	}
+
+	paths := make([]string, len(n.children))
+	position := map[xml.Name]int{}
+	for i, c := range n.children {
+		position[c.name]++
+		paths[i] = path + "/" + step(c)
+		if count[c.name] > 1 {
+			paths[i] += fmt.Sprintf("[%d]", position[c.name])
+		}
+	}
+	return paths
+}
+
+// compareMarkup returns the first difference between the got and want nodes
+// with the specified XPath, reported with the XPath of the differing node or
+// attribute, or an empty string if the nodes are equal.
+func compareMarkup(path string, got, want *node) string {
+	g, w := got.attrs, want.attrs
+	for i, j := 0, 0; i < len(g) || j < len(w); i, j = i+1, j+1 {
+		switch {
+		case j == len(w) || i < len(g) && lessName(g[i].Name, w[j].Name):
+			return "unexpected attribute: " + path + "/@" + attrName(g[i].Name)
+		case i == len(g) || lessName(w[j].Name, g[i].Name):
+			return "missing attribute: " + path + "/@" + attrName(w[j].Name)
+		case g[i].Value != w[j].Value:
+			return fmt.Sprintf("%s/@%s: got %q, want %q", path, attrName(w[j].Name), g[i].Value, w[j].Value)
+		}
+	}
+
+	gotPaths, wantPaths := got.childPaths(path), want.childPaths(path)
+	for i := 0; i < len(got.children) || i < len(want.children); i++ {
+		switch {
+		case i == len(want.children):
+			return "unexpected " + got.children[i].describe() + ": " + gotPaths[i]
+		case i == len(got.children):
+			return "missing " + want.children[i].describe() + ": " + wantPaths[i]
+		}
+
+		gc, wc := got.children[i], want.children[i]
+		switch {
+		case gc.name != wc.name || wc.name.Local == "" && gc.text != wc.text:
+			return fmt.Sprintf("%s: got %s, want %s", wantPaths[i], gc.describe(), wc.describe())
+		case wc.name.Local != "":
+			if d := compareMarkup(wantPaths[i], gc, wc); d != "" {
+				return d
+			}
+		}
+	}
+
+	return ""
+}
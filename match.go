@@ -6,30 +6,88 @@
 package golden
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/larhun/golden/internal/diff"
 )
 
 // match represents a matching test. All error messages are accumulated and
 // dumped when done. With one only error it passes whenever the found error
 // matches the expected error (used for inner testing).
 type match struct {
-	*testing.T
+	testing.TB
 
 	wantFail *string  // expected error (used for inner testing)
 	messages []string // accumulated error messages
+	checks   []check  // performed checks
+
+	ignoreColumns []string // ignored table columns
+	keyColumn     string   // table column matching rows
+	absTolerance  float64  // absolute tolerance of numbers
+	relTolerance  float64  // relative tolerance of numbers
+	unordered     bool     // lines matched regardless of order
+
+	vars map[string]interface{} // template variables
+
+	key string // gold master key, such as a query key, added to the test name
+}
+
+// check represents the result of matching an output.
+type check struct {
+	Name    string `json:"name"`              // checked field
+	Matcher string `json:"matcher"`           // match kind
+	Got     string `json:"got"`               // actual value
+	Want    string `json:"want"`              // expected value
+	File    string `json:"file,omitempty"`    // gold master file
+	Pass    bool   `json:"pass"`              // match result
+	Message string `json:"message,omitempty"` // error message
+	Diff    string `json:"diff,omitempty"`    // line differences
+
+	diffable bool // want is a text value to be line diffed
+}
+
+// actualFiles holds the actual flag, which is enabled by default by a non-empty
+// GOLDEN_ACTUAL environment variable.
+var actualFiles = flag.Bool("golden-actual", os.Getenv("GOLDEN_ACTUAL") != "", "write actual outputs of failed gold master matches to pending .actual files")
+
+// gotFiles holds the got flag, which is enabled by default by a non-empty
+// GOLDEN_GOT environment variable.
+var gotFiles = flag.Bool("golden-got", os.Getenv("GOLDEN_GOT") != "", "write actual outputs of failed matches to .got files")
+
+// addCheck accumulates the check and, if failed, its error message. A line
+// diff is added to failed diffable checks of text values. The expected failures
+// of the inner testing write no got files.
+func (m *match) addCheck(c check) {
+	if *gotFiles && m.wantFail == nil {
+		m.setGot(c)
+	}
+
+	if !c.Pass {
+		m.messages = append(m.messages, c.Message)
+		if c.diffable && !isBinary(c.Got) && !isBinary(c.Want) {
+			c.Diff = diff.Unified("want", "got", c.Want, c.Got)
+		}
+	}
+	m.checks = append(m.checks, c)
+}
+
+// addError accumulates the failed check of the named field with the specified
+// matcher, got string and error message, for an error preventing the match.
+func (m *match) addError(name, matcher, got, message string) {
+	m.addCheck(check{Name: name, Matcher: matcher, Got: got, Message: message})
 }
 
 // newMatch returns a new matching test with the specified fail message.
-func newMatch(t *testing.T, wantFail *string) *match {
+func newMatch(t testing.TB, wantFail *string) *match {
 	return &match{
-		T:        t,
+		TB:       t,
 		wantFail: wantFail,
 	}
 }
@@ -47,114 +105,299 @@ func (m *match) fail() {
 func (m *match) done() {
 	m.Helper()
 
+	if m.failed() {
+		m.fail()
+	}
+}
+
+// failed matches the expected fail error, if any, and reports whether the match
+// failed.
+func (m *match) failed() bool {
 	switch {
 
 	case m.wantFail == nil:
-		if len(m.messages) != 0 {
-			m.fail()
-		}
+		return len(m.messages) != 0
 
 	case len(m.messages) == 1:
-		if m.match("WantFail", m.messages[0], *m.wantFail); len(m.messages) != 1 {
-			m.fail() // expected fail error do not match current error
-		}
+		m.match("WantFail", m.messages[0], *m.wantFail)
+		return len(m.messages) != 1 // expected fail error do not match current error
 
 	default:
-		if m.match("WantFail", "", *m.wantFail); len(m.messages) != 0 {
-			m.fail() // expected fail error not found
-		}
-	}
-
-	return
-}
-
-// equal tests if the got value is equal to the want value. If not, accumulates
-// an error with the specified name.
-func (m *match) equal(name string, got, want int) {
-	if got != want {
-		message := name + " match error:\ngot: " + strconv.Itoa(got) + ", want: " + strconv.Itoa(want)
-		m.messages = append(m.messages, message)
+		m.match("WantFail", "", *m.wantFail)
+		return len(m.messages) != 0 // expected fail error not found
 	}
 }
 
 // match tests if the got string matches the want smart string. If not,
 // accumulates an error with the specified name.
 func (m *match) match(name, got, want string) {
-	n := len(want)
 	ext := filepath.Ext(want)
-	ok := false
+	c := check{Name: name, Matcher: "equal", Got: got, Want: want, diffable: true}
 
-	switch {
+	if ext == templateExt && want == "golden"+ext {
+		m.matchTemplate(name, got)
+		return
+	}
+
+	if _, ok := tableSeparators[ext]; ok && want == "golden"+ext {
+		m.matchTable(name, got, ext)
+		return
+	}
+
+	if _, ok := treeFormats[ext]; ok && want == "golden"+ext {
+		m.matchTree(name, got, ext)
+		return
+	}
 
-	case want == "golden"+ext:
+	if want == "golden"+ext {
+		c.Matcher = "golden" + ext
+		c.File = m.goldenFile(ext)
 		name += " golden" + ext
+
+		var ok bool
 		if want, ok = m.getGolden(name, ext, got); !ok {
 			return // file error
 		}
-		ok = got == want
+		c.Want = want
+		c.Pass = got == want
+		m.setActual(name, c.File, got, c.Pass)
+	} else {
+		var kind string
+		if kind, c.Pass = smart(got, want); kind != "" {
+			c.Matcher = kind
+			c.diffable = kind == "escaped"
+			name += " " + kind
+		}
+		if c.Matcher == "escaped" {
+			c.Want = want[1:]
+		}
+	}
+
+	if !c.Pass {
+		c.Message = format(name, got, want)
+	}
+	m.addCheck(c)
+}
+
+// matchOutput tests if the got output matches the want smart string as by
+// match, comparing the lines of a non-empty literal string, or of a plain gold
+// master, regardless of their order if unordered is true, else its numbers
+// within the tolerances if either one is not zero. If not, accumulates an error
+// with the specified name.
+func (m *match) matchOutput(name, got, want string) {
+	if kind, _ := smart(want, want); kind == "" && want != "" && !structured(want) {
+		switch {
+		case m.unordered:
+			m.matchLines(name, got, want)
+			return
+		case m.absTolerance != 0 || m.relTolerance != 0:
+			m.matchNumbers(name, got, want)
+			return
+		}
+	}
+
+	m.match(name, got, want)
+}
+
+// structured reports whether the want string is a gold master compared by a
+// format specific matcher, such as "golden.yaml".
+func structured(want string) bool {
+	ext := filepath.Ext(want)
+	if want != "golden"+ext {
+		return false
+	}
+
+	_, table := tableSeparators[ext]
+	_, tree := treeFormats[ext]
+	return ext == templateExt || table || tree
+}
+
+// smart reports whether the got string matches the want smart string and
+// returns the match kind, which is empty for an equality match. Gold masters
+// are not supported: a want string equal to "golden" represents itself.
+func smart(got, want string) (kind string, ok bool) {
+	n := len(want)
+
+	switch {
 
 	case n > 2 && want[0] == '^' && want[n-1] == '$':
 		if re, err := regexp.Compile(want); err == nil {
-			name += " pattern"
-			ok = re.MatchString(got)
-		} else {
-			ok = got == want
+			return "pattern", re.MatchString(got)
 		}
+		return "", got == want
 
 	case n > 6 && want[0:3] == "..." && want[n-3:n] == "...":
-		name += " substring"
-		ok = strings.Contains(got, want[3:len(want)-3])
+		return "substring", strings.Contains(got, want[3:n-3])
 
 	case n > 3 && want[n-3:n] == "...":
-		name += " prefix"
-		ok = strings.HasPrefix(got, want[:len(want)-3])
+		return "prefix", strings.HasPrefix(got, want[:n-3])
 
 	case n > 3 && want[0:3] == "...":
-		name += " suffix"
-		ok = strings.HasSuffix(got, want[3:])
+		return "suffix", strings.HasSuffix(got, want[3:])
 
 	case n > 1 && want[0] == '=':
-		name += " escaped"
-		ok = got == want[1:]
-
-	default:
-		ok = got == want
+		return "escaped", got == want[1:]
 	}
 
-	if !ok {
-		m.messages = append(m.messages, format(name, got, want))
-	}
+	return "", got == want
+}
 
-	return
+// literal returns a smart string that matches exactly the string s, escaping s
+// if it would be interpreted as a smart string.
+func literal(s string) string {
+	if kind, _ := smart(s, s); kind == "" {
+		return s
+	}
+	if kind, _ := smart(s, "="+s); kind == "escaped" {
+		return "=" + s
+	}
+	return "^" + regexp.QuoteMeta(s) + "$"
 }
 
 // getGolden returns the content of the named gold master file with the
 // specified extension and reports if succeeded. If the update flag is true,
 // writes the got string before reading.
 func (m *match) getGolden(name, ext, got string) (string, bool) {
-	file := filepath.Join(goldenDir, strings.Replace(m.Name(), "/", "-", -1)+ext)
+	file := m.goldenFile(ext)
 
-	if *update {
-		if err := os.MkdirAll(goldenDir, 0700); err != nil {
-			m.messages = append(m.messages, name+" folder error:\n"+err.Error())
-			return "", false
-		}
+	if *update && !m.writeGolden(name, file, got) {
+		return "", false
+	}
 
-		if err := ioutil.WriteFile(file, []byte(got), 0600); err != nil {
-			m.messages = append(m.messages, name+" update error:\n"+err.Error())
-			return "", false
-		}
+	return m.readGolden(name, file, got)
+}
+
+// writeGolden writes the got string to the named gold master file and reports
+// if succeeded.
+func (m *match) writeGolden(name, file, got string) bool {
+	if err := os.MkdirAll(goldenDir, 0700); err != nil {
+		m.fileError(name, file, got, name+" folder error:\n"+err.Error())
+		return false
 	}
 
+	if err := ioutil.WriteFile(file, []byte(got), 0600); err != nil {
+		m.fileError(name, file, got, name+" update error:\n"+err.Error())
+		return false
+	}
+
+	return true
+}
+
+// readGolden returns the content of the named gold master file and reports if
+// succeeded. If the file does not exist, the got string is written to the
+// pending actual file, if enabled.
+func (m *match) readGolden(name, file, got string) (string, bool) {
 	data, err := ioutil.ReadFile(file)
 	if err != nil {
-		m.messages = append(m.messages, name+" read error:\n"+err.Error())
+		m.fileError(name, file, got, name+" read error:\n"+err.Error())
+		if os.IsNotExist(err) {
+			m.setActual(name, file, got, false) // new gold master
+		}
 		return "", false
 	}
 
 	return string(data), true
 }
 
+// fileError accumulates the failed check of the named gold master file with
+// the specified error message. The checked field is the first word of the name.
+func (m *match) fileError(name, file, got, message string) {
+	m.addCheck(check{
+		Name:    strings.Fields(name)[0],
+		Matcher: "golden" + filepath.Ext(file),
+		Got:     got,
+		File:    file,
+		Message: message,
+
+		diffable: true,
+	})
+}
+
+// parseError accumulates the failed check of the named field, with the
+// specified matcher, whose got string cannot be parsed. The gold master with the
+// specified extension is updated to the got string, if enabled, and read as the
+// want string.
+func (m *match) parseError(field, matcher, ext, got, message string) {
+	name := field + " golden" + ext
+	c := check{
+		Name:    field,
+		Matcher: matcher,
+		Got:     got,
+		File:    m.goldenFile(ext),
+		Message: message,
+
+		diffable: true,
+	}
+
+	if *update && !m.writeGolden(name, c.File, got) {
+		return // file error
+	}
+	if data, err := ioutil.ReadFile(c.File); err == nil {
+		c.Want = string(data)
+	}
+
+	m.setActual(name, c.File, got, false)
+	m.addCheck(c)
+}
+
+// setActual writes the got string to the pending actual file of the named
+// gold master file if not passed and the actual flag is enabled, or removes it
+// if passed. The got flag also enables writing. Pending files are reviewed by
+// the golden command.
+func (m *match) setActual(name, file, got string, passed bool) {
+	actual := file + ".actual"
+
+	if passed {
+		if err := os.Remove(actual); err != nil && !os.IsNotExist(err) {
+			m.messages = append(m.messages, name+" remove error:\n"+err.Error())
+		}
+		return
+	}
+
+	if !*actualFiles && !*gotFiles {
+		return
+	}
+
+	if err := ioutil.WriteFile(actual, []byte(got), 0600); err != nil {
+		m.messages = append(m.messages, name+" write error:\n"+err.Error())
+	}
+}
+
+// setGot writes the got string of the failed diffable check to a file with the
+// ".got" extension in the gold master directory, along with a file with the
+// ".want" extension holding the want string, or removes the stale files if
+// passed. Gold master checks are ignored: their actual outputs are written to
+// the pending actual files.
+func (m *match) setGot(c check) {
+	if !c.diffable || c.File != "" {
+		return
+	}
+
+	file := m.goldenFile("." + c.Name)
+	for _, f := range [][2]string{{file + ".got", c.Got}, {file + ".want", c.Want}} {
+		if c.Pass {
+			if err := os.Remove(f[0]); err != nil && !os.IsNotExist(err) {
+				m.messages = append(m.messages, c.Name+" remove error:\n"+err.Error())
+			}
+			continue
+		}
+
+		err := os.MkdirAll(goldenDir, 0700)
+		if err == nil {
+			err = ioutil.WriteFile(f[0], []byte(f[1]), 0600)
+		}
+		if err != nil {
+			m.messages = append(m.messages, c.Name+" write error:\n"+err.Error())
+		}
+	}
+}
+
+// goldenFile returns the name of the gold master file with the specified
+// extension.
+func (m *match) goldenFile(ext string) string {
+	return filepath.Join(goldenDir, strings.Replace(m.Name(), "/", "-", -1)+m.key+ext)
+}
+
 // getFile returns the content and extension values of the named file and
 // reports if succeeded.
 func (m *match) getFile(name string) (got, ext string, ok bool) {
@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern holds the pattern of a decimal number.
+var numberPattern = regexp.MustCompile(`[-+]?(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?`)
+
+// maxNumberProblems holds the maximum number of reported number differences.
+const maxNumberProblems = 20
+
+// token represents a number or a run of other characters.
+type token struct {
+	text   string  // token text
+	number bool    // true for a number
+	value  float64 // number value
+	line   int     // line number, starting from 1
+}
+
+// tokenize splits the string into numbers and runs of other characters.
+func tokenize(s string) []token {
+	var tokens []token
+	line := 1
+	add := func(text string, number bool) {
+		t := token{text: text, number: number, line: line}
+		if number {
+			t.value, _ = strconv.ParseFloat(text, 64)
+		}
+		tokens = append(tokens, t)
+		line += strings.Count(text, "\n")
+	}
+
+	i := 0
+	for _, loc := range numberPattern.FindAllStringIndex(s, -1) {
+		if loc[0] > i {
+			add(s[i:loc[0]], false)
+		}
+		add(s[loc[0]:loc[1]], true)
+		i = loc[1]
+	}
+	if i < len(s) {
+		add(s[i:], false)
+	}
+	return tokens
+}
+
+// withinTolerance reports whether the numbers differ by no more than the
+// absolute tolerance or the relative tolerance of the largest magnitude.
+func withinTolerance(got, want, abs, rel float64) bool {
+	d := math.Abs(got - want)
+	return got == want || d <= abs || d <= rel*math.Max(math.Abs(got), math.Abs(want))
+}
+
+// compareNumbers returns the differences between the got and want strings.
+// Numbers are compared with the absolute and relative tolerances, and other
+// characters are compared exactly. The comparison stops at the first
+// difference of other characters.
+func compareNumbers(got, want string, abs, rel float64) []string {
+	var problems []string
+	g, w := tokenize(got), tokenize(want)
+
+	for i := 0; i < len(g) || i < len(w); i++ {
+		switch {
+		case i == len(w):
+			return append(problems, fmt.Sprintf("line %d: unexpected %q", g[i].line, g[i].text))
+		case i == len(g):
+			return append(problems, fmt.Sprintf("line %d: missing %q", w[i].line, w[i].text))
+		case g[i].number && w[i].number:
+			if !withinTolerance(g[i].value, w[i].value, abs, rel) {
+				problems = append(problems, fmt.Sprintf("line %d: got %s, want %s (difference %g)",
+					w[i].line, g[i].text, w[i].text, math.Abs(g[i].value-w[i].value)))
+			}
+		case g[i].text != w[i].text:
+			return append(problems, fmt.Sprintf("line %d: got %q, want %q", w[i].line, g[i].text, w[i].text))
+		}
+
+		if len(problems) == maxNumberProblems {
+			return append(problems, "and more differences")
+		}
+	}
+
+	return problems
+}
+
+// matchNumbers tests if the got string matches the want string, which is either
+// a literal string or "golden" with an optional extension, for a gold master,
+// comparing numbers with the tolerances of the match. If not, accumulates an
+// error with the specified name.
+func (m *match) matchNumbers(name, got, want string) {
+	c := check{Name: name, Matcher: "tolerance", Got: got, Want: want, diffable: true}
+
+	if ext := filepath.Ext(want); want == "golden"+ext {
+		c.File = m.goldenFile(ext)
+		name += " golden" + ext
+
+		var ok bool
+		if want, ok = m.getGolden(name, ext, got); !ok {
+			return // file error
+		}
+		c.Want = want
+	} else {
+		name += " tolerance"
+	}
+
+	problems := compareNumbers(got, want, m.absTolerance, m.relTolerance)
+	if c.Pass = len(problems) == 0; !c.Pass {
+		c.Message = name + " match error:\n" + strings.Join(problems, "\n")
+	}
+
+	if c.File != "" {
+		m.setActual(name, c.File, got, c.Pass)
+	}
+	m.addCheck(c)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+func (t *terminal) Run(args []string) error {
+	cmd, err := t.command(args)
+	if err != nil {
+		return err
+	}
+
+	master, slave, err := openPTY(t.term.Rows, t.term.Cols)
+	if err != nil {
+		t.exitCode = -1
+		return err
+	}
+	defer master.Close()
+
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	var output io.Writer = t.stdout
+	var s *screen
+	if t.term.Screen {
+		s = newScreen(int(t.term.Rows), int(t.term.Cols))
+		output = s
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(output, master) // ends with an EIO error when the slave is closed
+		close(copied)
+	}()
+
+	err = t.run(cmd, func() {
+		slave.Close()
+		go func(r io.Reader) {
+			if r != nil {
+				io.Copy(master, r)
+			}
+			master.Write([]byte{4}) // end of transmission, also for an empty input
+		}(t.stdin)
+	})
+	slave.Close() // if not started
+	<-copied
+
+	if s != nil {
+		io.WriteString(t.stdout, s.String())
+	}
+
+	return err
+}
+
+// openPTY opens a new pseudo-terminal with the specified window size and
+// returns its master and slave files.
+func openPTY(rows, cols uint16) (master, slave *os.File, err error) {
+	master, err = os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unlock int32
+	var n uint32
+	if err = ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err == nil {
+		err = ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&n))
+	}
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	slave, err = os.OpenFile("/dev/pts/"+strconv.Itoa(int(n)), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	size := [4]uint16{rows, cols, 0, 0}
+	if err = ioctl(slave, syscall.TIOCSWINSZ, unsafe.Pointer(&size)); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, nil, err
+	}
+
+	return master, slave, nil
+}
+
+// ioctl calls the ioctl system call on the file with the specified request and
+// argument.
+func ioctl(f *os.File, request uintptr, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return os.NewSyscallError("ioctl", errno)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package golden
+
+import "errors"
+
+func (t *terminal) Run(args []string) error {
+	if _, err := t.command(args); err != nil {
+		return err
+	}
+	t.exitCode = -1
+	return errors.New("pseudo-terminal not supported")
+}
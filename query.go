@@ -0,0 +1,281 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// step represents a step of a query: an object field, an array index, or a
+// function.
+type step struct {
+	key   string // object field
+	index *int   // array index, negative from the end
+	fn    string // function name
+}
+
+// parseQuery parses a query: a list of filters separated by the "|" character.
+// A filter is either a path, such as `.items[0].name` or `.["a key"]`, or one of
+// the functions length and keys.
+func parseQuery(q string) ([]step, error) {
+	var steps []step
+	for _, filter := range splitFilters(q) {
+		filter = strings.TrimSpace(filter)
+		switch {
+		case filter == "length" || filter == "keys":
+			steps = append(steps, step{fn: filter})
+		case strings.HasPrefix(filter, "."):
+			path, err := parsePath(filter)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, path...)
+		default:
+			return nil, errors.New("invalid filter: " + filter)
+		}
+	}
+	return steps, nil
+}
+
+// splitFilters splits the query at each "|" character outside of strings.
+func splitFilters(q string) []string {
+	var filters []string
+	start, quoted := 0, false
+	for i := 0; i < len(q); i++ {
+		switch {
+		case quoted && q[i] == '\\':
+			i++
+		case q[i] == '"':
+			quoted = !quoted
+		case !quoted && q[i] == '|':
+			filters = append(filters, q[start:i])
+			start = i + 1
+		}
+	}
+	return append(filters, q[start:])
+}
+
+// parsePath parses a path starting with the "." character.
+func parsePath(s string) ([]step, error) {
+	var steps []step
+	for i := 0; i < len(s); {
+		switch {
+
+		case s[i] == '.':
+			i++
+			start := i
+			for i < len(s) && (s[i] == '_' || isAlnum(s[i])) {
+				i++
+			}
+			if i > start {
+				steps = append(steps, step{key: s[start:i]})
+			} else if i < len(s) && s[i] != '[' {
+				return nil, fmt.Errorf("invalid path: %s", s)
+			}
+
+		case s[i] == '[':
+			end := strings.IndexByte(s[i:], ']')
+			if i+1 < len(s) && s[i+1] == '"' {
+				end = closingQuote(s, i+1) - i + 1
+			}
+			if end <= 0 || i+end >= len(s) || s[i+end] != ']' {
+				return nil, fmt.Errorf("invalid path: %s", s)
+			}
+			inner := s[i+1 : i+end]
+			i += end + 1
+
+			if strings.HasPrefix(inner, `"`) {
+				var key string
+				if err := json.Unmarshal([]byte(inner), &key); err != nil {
+					return nil, fmt.Errorf("invalid path: %s", s)
+				}
+				steps = append(steps, step{key: key})
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path: %s", s)
+			}
+			steps = append(steps, step{index: &n})
+
+		default:
+			return nil, fmt.Errorf("invalid path: %s", s)
+		}
+	}
+	return steps, nil
+}
+
+// isAlnum reports whether the character is an ASCII letter or digit.
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// closingQuote returns the index of the quote closing the string starting at
+// the specified index, or -1 if unterminated.
+func closingQuote(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// evalQuery returns the value of the query steps applied to the JSON value.
+func evalQuery(v interface{}, steps []step) (interface{}, error) {
+	for _, s := range steps {
+		switch {
+
+		case s.index != nil:
+			list, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index %s", jsonType(v))
+			}
+			i := *s.index
+			if i < 0 {
+				i += len(list)
+			}
+			if i < 0 || i >= len(list) {
+				return nil, fmt.Errorf("index out of range: %d (length %d)", *s.index, len(list))
+			}
+			v = list[i]
+
+		case s.fn == "length":
+			switch x := v.(type) {
+			case nil:
+				v = 0
+			case string:
+				v = utf8.RuneCountInString(x)
+			case []interface{}:
+				v = len(x)
+			case map[string]interface{}:
+				v = len(x)
+			default:
+				return nil, fmt.Errorf("%s has no length", jsonType(v))
+			}
+
+		case s.fn == "keys":
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s has no keys", jsonType(v))
+			}
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			list := make([]interface{}, len(keys))
+			for i, k := range keys {
+				list[i] = k
+			}
+			v = list
+
+		default:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot get field %q of %s", s.key, jsonType(v))
+			}
+			if v, ok = m[s.key]; !ok {
+				return nil, fmt.Errorf("missing field: %s", s.key)
+			}
+		}
+	}
+	return v, nil
+}
+
+// jsonType returns the JSON type name of the value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// queries tests if the values of the queries applied to the got JSON output
+// match the smart validation strings of the want map, in query order. If not,
+// accumulates an error for each failed query. String values are matched as
+// is, other values by their compact JSON encoding.
+func (m *match) queries(got string, want map[string]string) {
+	if len(want) == 0 {
+		return
+	}
+	name := "WantJSON"
+
+	d := json.NewDecoder(strings.NewReader(got))
+	d.UseNumber()
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		m.addError(name, "query", got, name+" parse error:\n"+err.Error())
+		return
+	}
+
+	queries := make([]string, 0, len(want))
+	for q := range want {
+		queries = append(queries, q)
+	}
+	sort.Strings(queries)
+
+	for _, q := range queries {
+		c := check{Name: name + " " + q, Matcher: "query", Got: got, Want: want[q]}
+
+		steps, err := parseQuery(q)
+		if err != nil {
+			c.Message = c.Name + " syntax error:\n" + err.Error()
+			m.addCheck(c)
+			continue
+		}
+
+		v, err := evalQuery(doc, steps)
+		if err != nil {
+			c.Message = c.Name + " query error:\n" + err.Error()
+			m.addCheck(c)
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			s = jsonText(v)
+		}
+		m.key = queryKey(q)
+		m.matchOutput(name+" "+q, s, want[q])
+		m.key = ""
+	}
+}
+
+// nonAlnum holds the pattern of the runs of characters other than letters and
+// digits.
+var nonAlnum = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// queryKey returns the gold master key of the query, added to the test name:
+// the dot followed by the letters and digits of the query, with each other run
+// of characters replaced by a dash, such as ".items-0-name" for the query
+// ".items[0].name".
+func queryKey(q string) string {
+	key := strings.Trim(nonAlnum.ReplaceAllString(q, "-"), "-")
+	if key == "" {
+		key = "root"
+	}
+	return "." + key
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// recorderExt holds the extension of the HTTP gold masters.
+const recorderExt = ".http.json"
+
+// ignoredHeaders holds the response headers that are not recorded.
+var ignoredHeaders = []string{"Connection", "Content-Length", "Date", "Keep-Alive", "Transfer-Encoding"}
+
+// hopHeaders holds the request headers that are not forwarded.
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Authorization", "Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// interaction represents a recorded HTTP request and response pair. A body
+// that is not valid UTF-8 text is encoded in base64.
+type interaction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	Body           string      `json:"body,omitempty"`
+	BodyBase64     bool        `json:"bodyBase64,omitempty"`
+	Status         int         `json:"status"`
+	Header         http.Header `json:"header,omitempty"`
+	Response       string      `json:"response"`
+	ResponseBase64 bool        `json:"responseBase64,omitempty"`
+}
+
+// encodeBody returns the text of the body and reports if encoded in base64.
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+// decodeBody returns the body of the text, encoded in base64 if specified.
+func decodeBody(text string, encoded bool) ([]byte, error) {
+	if encoded {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
+
+// Recorder represents a local HTTP server that records the interactions of
+// the commands under test with an HTTP service to a gold master when running
+// with the update flag, and replays them otherwise. Requests are matched by
+// method, URL and body, in order, and each recorded interaction is replayed
+// once.
+type Recorder struct {
+	t        testing.TB
+	server   *httptest.Server
+	upstream string       // upstream service URL, empty for a proxy
+	client   *http.Client // client of the upstream service
+	file     string       // gold master file
+
+	mutex        sync.Mutex
+	interactions []interaction // recorded or replayed interactions
+	replayed     []bool        // replayed interactions
+	messages     []string      // accumulated error messages
+
+	wantFail *string // expected error (used for inner testing)
+}
+
+// NewRecorder starts and returns a recorder of the interactions with the
+// upstream service URL, such as "https://api.example.com". If upstream is
+// empty, the recorder is an HTTP proxy of any service, as set by the
+// HTTP_PROXY environment variable, and HTTPS requests are not supported. The
+// gold master is stored in testdata/golden with name derived from the test
+// name and the .http.json extension. The recorder must be closed by calling
+// Close.
+func NewRecorder(t testing.TB, upstream string) *Recorder {
+	t.Helper()
+
+	m := newMatch(t, nil)
+	r := &Recorder{
+		t:        t,
+		upstream: strings.TrimSuffix(upstream, "/"),
+		file:     m.goldenFile(recorderExt),
+	}
+
+	if *update {
+		r.interactions = []interaction{}
+		r.client = &http.Client{
+			Transport: &http.Transport{}, // no proxy
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse // record redirects
+			},
+		}
+	} else {
+		data, err := ioutil.ReadFile(r.file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				t.Fatal("Recorder read error:\n" + err.Error() + "\nrun the test with the update flag to record the interactions")
+			}
+			t.Fatal("Recorder read error:\n" + err.Error())
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			t.Fatal("Recorder parse error:\n" + err.Error())
+		}
+		r.replayed = make([]bool, len(r.interactions))
+	}
+
+	r.server = httptest.NewServer(http.HandlerFunc(r.serve))
+	return r
+}
+
+// URL returns the base URL of the recorder, such as "http://127.0.0.1:34567".
+func (r *Recorder) URL() string {
+	return r.server.URL
+}
+
+// Env returns the named environment variable holding the recorder URL, such as
+// "HTTP_PROXY=http://127.0.0.1:34567", to be added to the process environment
+// of the command under test:
+//
+//	rec := NewRecorder(t, "https://api.example.com")
+//	defer rec.Close()
+//	command := Program("client", []string{rec.Env("API_URL")})
+func (r *Recorder) Env(name string) string {
+	return name + "=" + r.server.URL
+}
+
+// Close stops the recorder and, if the update flag is true, writes the
+// recorded interactions to the gold master. The test fails if an unexpected
+// request was received.
+func (r *Recorder) Close() {
+	r.t.Helper()
+
+	r.server.Close()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m := newMatch(r.t, r.wantFail)
+	m.messages = r.messages
+
+	if *update {
+		var b bytes.Buffer
+		e := json.NewEncoder(&b)
+		e.SetEscapeHTML(false)
+		e.SetIndent("", "  ")
+		if err := e.Encode(r.interactions); err != nil {
+			m.messages = append(m.messages, "Recorder encode error:\n"+err.Error())
+		} else {
+			m.writeGolden("Recorder", r.file, b.String())
+		}
+	}
+
+	m.done()
+}
+
+// serve serves a request by forwarding it to the upstream service and
+// recording the interaction, if the update flag is true, or by replaying the
+// matching interaction.
+func (r *Recorder) serve(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		r.fail(w, http.StatusBadRequest, "Recorder request error:\n"+err.Error())
+		return
+	}
+
+	target := req.URL.String()
+	if r.upstream == "" && (req.Method == http.MethodConnect || !req.URL.IsAbs()) {
+		r.fail(w, http.StatusNotImplemented, "Recorder proxy error:\nunsupported request: "+req.Method+" "+req.RequestURI)
+		return
+	}
+
+	if *update {
+		r.record(w, req, target, body)
+		return
+	}
+
+	r.mutex.Lock()
+	var found *interaction
+	for i, x := range r.interactions {
+		if !r.replayed[i] && x.Method == req.Method && x.URL == target {
+			if want, err := decodeBody(x.Body, x.BodyBase64); err == nil && bytes.Equal(want, body) {
+				r.replayed[i], found = true, &r.interactions[i]
+				break
+			}
+		}
+	}
+	r.mutex.Unlock()
+
+	if found == nil {
+		r.fail(w, http.StatusBadGateway, "Recorder match error:\nunexpected request: "+req.Method+" "+target)
+		return
+	}
+
+	response, err := decodeBody(found.Response, found.ResponseBase64)
+	if err != nil {
+		r.fail(w, http.StatusBadGateway, "Recorder parse error:\n"+err.Error())
+		return
+	}
+	for key, values := range found.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(found.Status)
+	w.Write(response)
+}
+
+// record forwards the request to the upstream service, records the interaction
+// and copies the response.
+func (r *Recorder) record(w http.ResponseWriter, req *http.Request, target string, body []byte) {
+	out, err := http.NewRequest(req.Method, r.upstream+target, bytes.NewReader(body))
+	if err != nil {
+		r.fail(w, http.StatusBadGateway, "Recorder request error:\n"+err.Error())
+		return
+	}
+	for key, values := range req.Header {
+		out.Header[key] = values
+	}
+	for _, key := range hopHeaders {
+		out.Header.Del(key)
+	}
+
+	resp, err := r.client.Do(out)
+	if err != nil {
+		r.fail(w, http.StatusBadGateway, "Recorder upstream error:\n"+err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	response, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		r.fail(w, http.StatusBadGateway, "Recorder upstream error:\n"+err.Error())
+		return
+	}
+
+	x := interaction{Method: req.Method, URL: target, Status: resp.StatusCode, Header: http.Header{}}
+	x.Body, x.BodyBase64 = encodeBody(body)
+	x.Response, x.ResponseBase64 = encodeBody(response)
+	for key, values := range resp.Header {
+		x.Header[key] = values
+	}
+	for _, key := range ignoredHeaders {
+		x.Header.Del(key)
+	}
+
+	r.mutex.Lock()
+	r.interactions = append(r.interactions, x)
+	r.mutex.Unlock()
+
+	for key, values := range x.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(x.Status)
+	w.Write(response)
+}
+
+// fail accumulates the error message and responds with the status code and
+// the message.
+func (r *Recorder) fail(w http.ResponseWriter, status int, message string) {
+	r.mutex.Lock()
+	r.messages = append(r.messages, message)
+	r.mutex.Unlock()
+
+	http.Error(w, message, status)
+}
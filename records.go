@@ -0,0 +1,273 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// volatileFields holds the names of the record fields ignored by the JSON Lines
+// gold masters.
+var volatileFields = []string{"time", "ts", "timestamp"}
+
+// jsonlFormat holds the JSON Lines format.
+var jsonlFormat = treeFormat{
+	name: "jsonl",
+	parse: func(s string) (interface{}, error) {
+		records, err := parseRecords(s)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if r, ok := r.(map[string]interface{}); ok {
+				for _, field := range volatileFields {
+					delete(r, field)
+				}
+			}
+		}
+		return records, nil
+	},
+	format: func(v interface{}) string {
+		var b strings.Builder
+		for _, r := range v.([]interface{}) {
+			b.WriteString(jsonText(r) + "\n")
+		}
+		return b.String()
+	},
+	compare: func(got, want interface{}) []string {
+		return compareTree("", got, want, jsonText)
+	},
+}
+
+// parseRecords parses each non-empty line of the string as a JSON value.
+func parseRecords(s string) ([]interface{}, error) {
+	records := []interface{}{}
+	for i, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		var r interface{}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// jsonText returns the JSON encoding of the value, with sorted object keys.
+func jsonText(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// condition represents a field condition of a record expression.
+type condition struct {
+	field string         // dotted field path
+	op    byte           // '=' for equality, '~' for pattern, 0 for presence
+	value interface{}    // expected value
+	re    *regexp.Regexp // expected pattern
+	text  string         // expected value or pattern as written
+}
+
+// parseExpression parses a record expression, which is a list of conditions
+// separated by spaces. A condition is a dotted field path, optionally followed
+// by "=" and a value, for equality, or by "~" and a regular expression, for a
+// partial pattern match. A value is a JSON string, number, boolean or null, or
+// any other sequence of non-space characters, representing a string. A
+// regular expression is either a JSON string or a sequence of non-space
+// characters.
+func parseExpression(expr string) ([]condition, error) {
+	var conditions []condition
+	for i := 0; i < len(expr); {
+		if expr[i] == ' ' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(expr) && strings.IndexByte(" =~", expr[i]) < 0 {
+			i++
+		}
+		c := condition{field: expr[start:i]}
+		if c.field == "" {
+			return nil, errors.New("missing field name: " + expr[start:])
+		}
+		if i == len(expr) || expr[i] == ' ' {
+			conditions = append(conditions, c)
+			continue
+		}
+		c.op = expr[i]
+		i++
+
+		start = i
+		var quoted string
+		isQuoted := i < len(expr) && expr[i] == '"'
+		if isQuoted {
+			for i++; i < len(expr) && expr[i] != '"'; i++ {
+				if expr[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(expr) {
+				return nil, errors.New("unterminated string: " + expr[start:])
+			}
+			i++
+			if err := json.Unmarshal([]byte(expr[start:i]), &quoted); err != nil {
+				return nil, errors.New("invalid string: " + expr[start:i])
+			}
+		} else {
+			for i < len(expr) && expr[i] != ' ' {
+				i++
+			}
+		}
+		c.text = expr[start:i]
+
+		switch {
+		case c.op == '~':
+			pattern := c.text
+			if isQuoted {
+				pattern = quoted
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			c.re = re
+		case isQuoted:
+			c.value = quoted
+		case json.Unmarshal([]byte(c.text), &c.value) != nil:
+			c.value = c.text // bare string
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions, nil
+}
+
+// lookup returns the value of the record field with the specified dotted path
+// and reports if found.
+func lookup(record interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		m, ok := record.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if record, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return record, true
+}
+
+// checkRecord returns the first condition not met by the record, described, or
+// an empty string if all the conditions are met.
+func checkRecord(record interface{}, conditions []condition) string {
+	for _, c := range conditions {
+		got, ok := lookup(record, c.field)
+		if !ok {
+			return fmt.Sprintf("field %q: missing", c.field)
+		}
+
+		switch c.op {
+
+		case '=':
+			if !reflect.DeepEqual(got, c.value) {
+				return fmt.Sprintf("field %q: got %s, want %s", c.field, jsonText(got), jsonText(c.value))
+			}
+
+		case '~':
+			s, ok := got.(string)
+			if !ok {
+				s = jsonText(got)
+			}
+			if !c.re.MatchString(s) {
+				return fmt.Sprintf("field %q: got %s, want match of %s", c.field, jsonText(got), c.text)
+			}
+		}
+	}
+	return ""
+}
+
+// compareRecords returns the differences between the got records and the want
+// expressions. If subset is true, the expressions must match a subsequence of
+// the records, else each expression must match the record at the same position
+// and all the records must be matched. Records are numbered from 1.
+func compareRecords(records []interface{}, want [][]condition, exprs []string, subset bool) []string {
+	var problems []string
+
+	if subset {
+		next := 0
+		for i, conditions := range want {
+			found := false
+			for j := next; j < len(records); j++ {
+				if checkRecord(records[j], conditions) == "" {
+					found, next = true, j+1
+					break
+				}
+			}
+			if !found {
+				problems = append(problems, "missing record: "+exprs[i])
+			}
+		}
+		return problems
+	}
+
+	for i, conditions := range want {
+		if i == len(records) {
+			problems = append(problems, fmt.Sprintf("missing record %d: %s", i+1, exprs[i]))
+			continue
+		}
+		if d := checkRecord(records[i], conditions); d != "" {
+			problems = append(problems, fmt.Sprintf("record %d: %s", i+1, d))
+		}
+	}
+	for i := len(want); i < len(records); i++ {
+		problems = append(problems, fmt.Sprintf("unexpected record %d: %s", i+1, jsonText(records[i])))
+	}
+	return problems
+}
+
+// records tests if the records of the got JSON Lines output match the want
+// record expressions, as a sequence or, if subset is true, as a subsequence.
+// If not, accumulates an error. Nothing is tested if want is nil.
+func (m *match) records(got string, want []string, subset bool) {
+	if want == nil {
+		return
+	}
+	name := "WantLogs"
+	c := check{Name: name, Matcher: "records", Got: got, Want: strings.Join(want, "\n")}
+
+	conditions := make([][]condition, len(want))
+	for i, expr := range want {
+		var err error
+		if conditions[i], err = parseExpression(expr); err != nil {
+			c.Message = name + " syntax error:\n" + err.Error()
+			m.addCheck(c)
+			return
+		}
+	}
+
+	records, err := parseRecords(got)
+	if err != nil {
+		c.Message = name + " parse error:\n" + err.Error()
+		m.addCheck(c)
+		return
+	}
+
+	problems := compareRecords(records, conditions, want, subset)
+	if c.Pass = len(problems) == 0; !c.Pass {
+		c.Message = name + " match error:\n" + strings.Join(problems, "\n")
+	}
+	m.addCheck(c)
+}
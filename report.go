@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+// reportFile holds the name of the JSON report file.
+var reportFile = flag.String("golden-report", "", "write a JSON report of the test cases to `path`")
+
+// htmlDir holds the directory of the HTML report.
+var htmlDir = flag.String("golden-html", "", "write an HTML report of the test cases to `dir`")
+
+// outputs represents the outputs of a command run.
+type outputs struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	Panic    string `json:"panic"`
+	Err      string `json:"err"`
+	ExitCode int    `json:"exitCode"`
+	Signal   string `json:"signal,omitempty"`
+}
+
+// caseReport represents the report of a test case.
+type caseReport struct {
+	Test     string   `json:"test"`               // full test name
+	Name     string   `json:"name"`               // case name
+	Args     []string `json:"args"`               // argument list
+	Stdin    string   `json:"stdin,omitempty"`    // standard input
+	File     string   `json:"file,omitempty"`     // tested file
+	Outputs  outputs  `json:"outputs"`            // actual outputs
+	Pass     bool     `json:"pass"`               // test result
+	Checks   []check  `json:"checks"`             // performed checks
+	Messages []string `json:"messages,omitempty"` // error messages
+}
+
+// reportCollector collects the reports of the test cases.
+type reportCollector struct {
+	sync.Mutex
+	cases []caseReport
+}
+
+// reports holds the reports of all the test cases of the run.
+var reports = new(reportCollector)
+
+// report adds the report of the test case with the specified outputs and
+// result to the reports of the run, if enabled.
+func (m *match) report(tc Case, out outputs, pass bool) {
+	if *reportFile == "" && *htmlDir == "" {
+		return
+	}
+
+	r := reports
+	r.Lock()
+	defer r.Unlock()
+
+	r.cases = append(r.cases, caseReport{
+		Test:     m.Name(),
+		Name:     tc.Name,
+		Args:     tc.Args,
+		Stdin:    tc.Stdin,
+		File:     tc.WantFile,
+		Outputs:  out,
+		Pass:     pass,
+		Checks:   m.checks,
+		Messages: m.messages,
+	})
+}
+
+// writeReports writes the reports of all the test cases of the run to the
+// enabled JSON and HTML reports.
+func writeReports() error {
+	r := reports
+	r.Lock()
+	defer r.Unlock()
+
+	if *reportFile != "" {
+		if err := writeJSON(*reportFile, r.cases); err != nil {
+			return errors.New("Report write error:\n" + err.Error())
+		}
+	}
+
+	if *htmlDir != "" {
+		if err := writeHTML(*htmlDir, r.cases); err != nil {
+			return errors.New("HTML report write error:\n" + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Main runs the tests, writes the enabled reports of all the test cases once
+// at the end of the run and exits. It is called by the TestMain function of
+// the tested package:
+//
+//	func TestMain(m *testing.M) {
+//	    Main(m)
+//	}
+func Main(m *testing.M) {
+	code := m.Run()
+
+	if err := writeReports(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if code == 0 {
+			code = 1
+		}
+	}
+
+	os.Exit(code)
+}
+
+// writeJSON writes the JSON report of the test cases to the named file.
+func writeJSON(name string, cases []caseReport) error {
+	data, err := json.MarshalIndent(struct {
+		Cases []caseReport `json:"cases"`
+	}{cases}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, append(data, '\n'), 0600)
+}
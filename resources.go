@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"fmt"
+	"time"
+)
+
+// Usage represents the resources used by a command run.
+type Usage struct {
+	MaxRSS  int64         // maximum resident set size, in bytes
+	CPUTime time.Duration // user and system CPU time
+}
+
+// UsageReporter is the optional interface implemented by a Runner that reports
+// the resources used by its last run.
+type UsageReporter interface {
+	// Usage returns the resources used by the last run and reports if
+	// available.
+	Usage() (Usage, bool)
+}
+
+func (p *program) Usage() (Usage, bool) {
+	if p.state == nil {
+		return Usage{}, false
+	}
+	return processUsage(p.state)
+}
+
+// formatBytes returns the size in bytes, followed by the size in mebibytes.
+func formatBytes(n int64) string {
+	return fmt.Sprintf("%d bytes (%.1f MiB)", n, float64(n)/(1<<20))
+}
+
+// limits tests if the duration of the last run of the command and, if set up,
+// the resources it used do not exceed the limits of the test case. If not,
+// accumulates an error for each exceeded limit. Resource limits require a
+// command implementing the UsageReporter interface.
+func (m *match) limits(command Runner, tc Case, duration time.Duration) {
+	if tc.MaxDuration > 0 {
+		m.limit("MaxDuration", duration <= tc.MaxDuration, duration.String(), tc.MaxDuration.String())
+	}
+
+	if tc.MaxRSS == 0 && tc.MaxCPU == 0 {
+		return
+	}
+
+	var usage Usage
+	r, ok := command.(UsageReporter)
+	if ok {
+		usage, ok = r.Usage()
+	}
+	if !ok {
+		m.messages = append(m.messages, "Usage error:\nresource usage not reported by the runner")
+		return
+	}
+
+	if tc.MaxRSS > 0 {
+		m.limit("MaxRSS", usage.MaxRSS <= tc.MaxRSS, formatBytes(usage.MaxRSS), formatBytes(tc.MaxRSS))
+	}
+	if tc.MaxCPU > 0 {
+		m.limit("MaxCPU", usage.CPUTime <= tc.MaxCPU, usage.CPUTime.String(), tc.MaxCPU.String())
+	}
+}
+
+// limit accumulates the check of the named limit. If not passed, accumulates an
+// error with the got value and the max value.
+func (m *match) limit(name string, pass bool, got, max string) {
+	c := check{Name: name, Matcher: "limit", Got: got, Want: "at most " + max, Pass: pass}
+	if !pass {
+		c.Message = name + " match error:\ngot: " + got + ", want: at most " + max
+	}
+	m.addCheck(c)
+}
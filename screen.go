@@ -0,0 +1,299 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// screen represents a virtual terminal screen that interprets the written
+// characters and escape sequences. A zero number of rows or columns represents
+// an unbounded size. A newline also returns the cursor to the first column.
+type screen struct {
+	rows, cols int      // size
+	lines      [][]rune // screen content
+	row, col   int      // cursor position
+	saved      [2]int   // saved cursor position
+	pending    []byte   // incomplete sequence
+}
+
+// newScreen returns a new screen with the specified size.
+func newScreen(rows, cols int) *screen {
+	return &screen{rows: rows, cols: cols}
+}
+
+// Write interprets the bytes and updates the screen. It always succeeds.
+func (s *screen) Write(p []byte) (int, error) {
+	data := append(s.pending, p...)
+	s.pending = nil
+
+	for len(data) > 0 {
+		n := s.next(data)
+		if n == 0 {
+			s.pending = append([]byte(nil), data...) // wait for more bytes
+			break
+		}
+		data = data[n:]
+	}
+
+	return len(p), nil
+}
+
+// next interprets the first character or escape sequence of the data and
+// returns its length, or 0 if incomplete.
+func (s *screen) next(data []byte) int {
+	switch c := data[0]; c {
+
+	case '\r':
+		s.col = 0
+
+	case '\n':
+		s.col = 0
+		s.lineFeed()
+
+	case '\b':
+		if s.col > 0 {
+			s.col--
+		}
+
+	case '\t':
+		s.col = (s.col/8 + 1) * 8
+		if s.cols > 0 && s.col >= s.cols {
+			s.col = s.cols - 1
+		}
+
+	case 0x1b:
+		return s.escape(data)
+
+	default:
+		if c < 0x20 || c == 0x7f {
+			return 1 // ignore other control characters
+		}
+		if !utf8.FullRune(data) {
+			return 0
+		}
+		r, n := utf8.DecodeRune(data)
+		s.put(r)
+		return n
+	}
+
+	return 1
+}
+
+// escape interprets the escape sequence at the start of the data and returns
+// its length, or 0 if incomplete.
+func (s *screen) escape(data []byte) int {
+	if len(data) < 2 {
+		return 0
+	}
+
+	switch data[1] {
+
+	case '[': // control sequence
+		for i := 2; i < len(data); i++ {
+			if c := data[i]; c >= 0x40 && c <= 0x7e {
+				s.control(string(data[2:i]), c)
+				return i + 1
+			}
+		}
+		return 0
+
+	case ']': // operating system command, ignored
+		for i := 2; i < len(data); i++ {
+			if data[i] == 0x07 {
+				return i + 1
+			}
+			if data[i] == 0x1b && i+1 < len(data) && data[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return 0
+
+	case '7':
+		s.saved = [2]int{s.row, s.col}
+
+	case '8':
+		s.row, s.col = s.saved[0], s.saved[1]
+	}
+
+	return 2
+}
+
+// control interprets the control sequence with the specified parameters and
+// final byte. Unsupported sequences, such as the SGR ones, are ignored.
+func (s *screen) control(params string, final byte) {
+	if strings.HasPrefix(params, "?") {
+		return // private mode
+	}
+
+	args := strings.Split(params, ";")
+	arg := func(i, def int) int {
+		if i < len(args) {
+			if n, err := strconv.Atoi(args[i]); err == nil && n > 0 {
+				return n
+			}
+		}
+		return def
+	}
+
+	switch final {
+
+	case 'A':
+		s.row -= arg(0, 1)
+		if s.row < 0 {
+			s.row = 0
+		}
+
+	case 'B':
+		s.row += arg(0, 1)
+
+	case 'C':
+		s.col += arg(0, 1)
+
+	case 'D':
+		s.col -= arg(0, 1)
+
+	case 'E':
+		s.row += arg(0, 1)
+		s.col = 0
+
+	case 'F':
+		s.row -= arg(0, 1)
+		s.col = 0
+
+	case 'G':
+		s.col = arg(0, 1) - 1
+
+	case 'H', 'f':
+		s.row, s.col = arg(0, 1)-1, arg(1, 1)-1
+
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+
+	case 'K':
+		s.eraseLine(arg(0, 0))
+
+	case 's':
+		s.saved = [2]int{s.row, s.col}
+
+	case 'u':
+		s.row, s.col = s.saved[0], s.saved[1]
+	}
+
+	s.clip()
+}
+
+// clip keeps the cursor within the screen.
+func (s *screen) clip() {
+	if s.row < 0 {
+		s.row = 0
+	}
+	if s.rows > 0 && s.row >= s.rows {
+		s.row = s.rows - 1
+	}
+	if s.col < 0 {
+		s.col = 0
+	}
+	if s.cols > 0 && s.col >= s.cols {
+		s.col = s.cols - 1
+	}
+}
+
+// lineFeed moves the cursor to the next line, scrolling the screen if needed.
+func (s *screen) lineFeed() {
+	s.row++
+	if s.rows > 0 && s.row >= s.rows {
+		s.line(s.rows - 1) // extend the screen to scroll off its first row
+		s.lines = s.lines[1:]
+		s.row = s.rows - 1
+	}
+}
+
+// line returns the line at the specified row, extending the screen if needed.
+func (s *screen) line(row int) []rune {
+	for len(s.lines) <= row {
+		s.lines = append(s.lines, nil)
+	}
+	return s.lines[row]
+}
+
+// put writes the rune at the cursor position and advances the cursor,
+// wrapping at the end of the line.
+func (s *screen) put(r rune) {
+	if s.cols > 0 && s.col >= s.cols {
+		s.col = 0
+		s.lineFeed()
+	}
+
+	line := s.line(s.row)
+	for len(line) <= s.col {
+		line = append(line, ' ')
+	}
+	line[s.col] = r
+	s.lines[s.row] = line
+	s.col++
+}
+
+// eraseLine erases the current line from the cursor (mode 0), up to the cursor
+// (mode 1) or entirely (mode 2).
+func (s *screen) eraseLine(mode int) {
+	line := s.line(s.row)
+	switch mode {
+
+	case 0:
+		if s.col < len(line) {
+			line = line[:s.col]
+		}
+
+	case 1:
+		for i := 0; i <= s.col && i < len(line); i++ {
+			line[i] = ' '
+		}
+
+	default:
+		line = nil
+	}
+	s.lines[s.row] = line
+}
+
+// eraseDisplay erases the screen from the cursor (mode 0), up to the cursor
+// (mode 1) or entirely (modes 2 and 3).
+func (s *screen) eraseDisplay(mode int) {
+	s.line(s.row)
+	switch mode {
+
+	case 0:
+		s.eraseLine(0)
+		s.lines = s.lines[:s.row+1]
+
+	case 1:
+		s.eraseLine(1)
+		for i := 0; i < s.row; i++ {
+			s.lines[i] = nil
+		}
+
+	default:
+		s.lines = nil
+	}
+}
+
+// String returns the screen content. Trailing spaces and empty lines are
+// removed and each line ends with a newline.
+func (s *screen) String() string {
+	n := len(s.lines)
+	for n > 0 && strings.TrimRight(string(s.lines[n-1]), " ") == "" {
+		n--
+	}
+
+	var b strings.Builder
+	for _, line := range s.lines[:n] {
+		b.WriteString(strings.TrimRight(string(line), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// StdinSetter is the optional interface implemented by a Runner that reads from
+// a standard input.
+type StdinSetter interface {
+	// SetStdin sets the command's standard input. A nil reader represents an
+	// empty input.
+	SetStdin(r io.Reader)
+}
+
+// setStdin sets the standard input of the command. If the input is not empty,
+// the command must implement the StdinSetter interface.
+func (m *match) setStdin(command Runner, stdin string) {
+	s, ok := command.(StdinSetter)
+	switch {
+
+	case ok && stdin == "":
+		s.SetStdin(nil)
+
+	case ok:
+		s.SetStdin(strings.NewReader(stdin))
+
+	case stdin != "":
+		m.addError("Stdin", "stdin", stdin, "Stdin error:\nrunner does not implement StdinSetter")
+	}
+}
+
+// closeTimeout holds the default time Close waits for the command to exit.
+const closeTimeout = 5 * time.Second
+
+// Session represents an expect-style interaction with a running command. The
+// standard and error outputs are merged into one output stream, as shown by a
+// terminal. The transcript records the consumed output and the sent lines.
+type Session struct {
+	// CloseTimeout holds the time Close waits for the command to exit after
+	// closing its standard input, 5 seconds by default.
+	CloseTimeout time.Duration
+
+	t       testing.TB
+	command Runner
+	stdin   *io.PipeWriter
+	kill    chan struct{} // closed to kill the command
+	killed  sync.Once
+
+	mutex   sync.Mutex
+	output  bytes.Buffer  // unread output
+	changed chan struct{} // signaled on output change
+
+	transcript bytes.Buffer // consumed output and sent lines
+
+	done  chan struct{} // closed on exit
+	err   error         // error returned by Run
+	panic string        // panic message
+}
+
+// NewSession runs the command with the specified argument list and returns the
+// session used to interact with it. The command must implement the StdinSetter
+// interface. If it implements the Signaler interface, the command is killed
+// when it does not exit in time on Close or when a session method fails the
+// test. The session must be closed by calling Close.
+func NewSession(t testing.TB, command Runner, args []string) *Session {
+	t.Helper()
+
+	stdin, ok := command.(StdinSetter)
+	if !ok {
+		t.Fatal("Session error:\nrunner does not implement StdinSetter")
+	}
+
+	r, w := io.Pipe()
+	s := &Session{
+		CloseTimeout: closeTimeout,
+		t:            t,
+		command:      command,
+		stdin:        w,
+		kill:         make(chan struct{}),
+		changed:      make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+
+	stdin.SetStdin(r)
+	if signaler, ok := command.(Signaler); ok {
+		signaler.SetSignal(os.Kill, s.kill)
+	}
+	command.SetStdout(sessionWriter{s})
+	command.SetStderr(sessionWriter{s})
+
+	go func() {
+		defer close(s.done)
+		defer r.Close()
+		if signaler, ok := command.(Signaler); ok {
+			defer signaler.SetSignal(nil, nil)
+		}
+		s.panic = newMatch(t, nil).run(func() {
+			s.err = command.Run(args)
+		})
+	}()
+
+	return s
+}
+
+// sessionWriter represents the writer of a session output.
+type sessionWriter struct {
+	s *Session
+}
+
+func (w sessionWriter) Write(p []byte) (int, error) {
+	w.s.mutex.Lock()
+	defer w.s.mutex.Unlock()
+
+	w.s.output.Write(p)
+	select {
+	case w.s.changed <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Expect waits until the unread output matches the regular expression pattern,
+// consumes the output up to the end of the match and returns the matched text.
+// The test fails if no match is found before the timeout expires or the
+// command exits. On failure, the command is stopped as on Close.
+func (s *Session) Expect(pattern string, timeout time.Duration) string {
+	s.t.Helper()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		s.fatal("Expect syntax error:\n" + err.Error())
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	exited := false
+	for {
+		s.mutex.Lock()
+		unread := s.output.Bytes()
+		if loc := re.FindIndex(unread); loc != nil {
+			match := string(unread[loc[0]:loc[1]])
+			s.transcript.Write(s.output.Next(loc[1]))
+			s.mutex.Unlock()
+			return match
+		}
+		got := string(unread)
+		s.mutex.Unlock()
+
+		if exited {
+			s.fatal(format("Expect exit", got, pattern))
+		}
+
+		select {
+		case <-s.changed:
+		case <-s.done:
+			exited = true // check the remaining output once more
+		case <-timer.C:
+			s.fatal(format("Expect timeout", got, pattern))
+		}
+	}
+}
+
+// Send writes the line, followed by a newline, to the standard input of the
+// command and records it in the transcript.
+func (s *Session) Send(line string) {
+	s.t.Helper()
+
+	s.mutex.Lock()
+	s.transcript.WriteString(line + "\n")
+	s.mutex.Unlock()
+
+	if _, err := io.WriteString(s.stdin, line+"\n"); err != nil {
+		s.fatal("Send error:\n" + err.Error())
+	}
+}
+
+// Close closes the standard input of the command, waits for the command to
+// exit and consumes the remaining output. It returns the error returned by the
+// command. If the command does not exit before the close timeout expires, it is
+// killed as by a Signaler, and the error reports the kill signal. The test fails
+// if the command panics or cannot be killed.
+func (s *Session) Close() error {
+	s.t.Helper()
+
+	s.stdin.Close()
+
+	timer := time.NewTimer(s.CloseTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-s.done:
+	case <-timer.C:
+		if _, ok := s.command.(Signaler); !ok {
+			s.t.Fatal("Session error:\ncommand did not exit and runner does not implement Signaler")
+		}
+		s.stop()
+		<-s.done
+	}
+
+	s.mutex.Lock()
+	s.transcript.Write(s.output.Next(s.output.Len()))
+	s.mutex.Unlock()
+
+	if s.panic != "" {
+		s.t.Fatal("Session panic:\n" + s.panic)
+	}
+
+	return s.err
+}
+
+// stop closes the standard input of the command and kills it, if the runner
+// implements the Signaler interface.
+func (s *Session) stop() {
+	s.stdin.Close()
+	s.killed.Do(func() { close(s.kill) })
+}
+
+// fatal stops the command, so that it does not outlive the failed test, and
+// fails the test with the message.
+func (s *Session) fatal(message string) {
+	s.t.Helper()
+
+	s.stop()
+	s.t.Fatal(message)
+}
+
+// Transcript returns the transcript of the session.
+func (s *Session) Transcript() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.transcript.String()
+}
+
+// Match tests if the transcript matches the want smart validation string, such
+// as "golden" to compare it to a gold master. If not, the test fails.
+func (s *Session) Match(want string) {
+	s.t.Helper()
+
+	m := newMatch(s.t, nil)
+	m.match("Transcript", s.Transcript(), want)
+	m.done()
+}
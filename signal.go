@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Signaler is the optional interface implemented by a Runner that can be sent a
+// signal while running.
+type Signaler interface {
+	// SetSignal sets the signal sent to the command as soon as the trigger
+	// channel is closed during a run. A nil signal disables sending.
+	SetSignal(sig os.Signal, trigger <-chan struct{})
+}
+
+// setSignal sets up the command to be sent the signal of the test case and
+// returns the writer to be used as standard output and a cleanup function.
+func (m *match) setSignal(command Runner, tc Case, stdout io.Writer) (io.Writer, func()) {
+	if tc.Signal == nil {
+		return stdout, func() {}
+	}
+
+	s, ok := command.(Signaler)
+	if !ok {
+		m.messages = append(m.messages, "Signal error:\nrunner does not implement Signaler")
+		return stdout, func() {}
+	}
+
+	trigger := make(chan struct{})
+	var once sync.Once
+	var timer *time.Timer
+	var mutex sync.Mutex
+
+	fire := func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		once.Do(func() {
+			timer = time.AfterFunc(tc.SignalDelay, func() { close(trigger) })
+		})
+	}
+
+	if tc.SignalAfter != "" {
+		stdout = &lineWatcher{w: stdout, want: tc.SignalAfter, fire: fire}
+	} else {
+		fire()
+	}
+
+	s.SetSignal(tc.Signal, trigger)
+
+	return stdout, func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		s.SetSignal(nil, nil)
+	}
+}
+
+// lineWatcher represents a writer that calls the fire function once a written
+// line matches the want smart string. A carriage return ending a line, as
+// written by a terminal, is ignored.
+type lineWatcher struct {
+	w    io.Writer // underlying writer
+	want string    // smart string
+	fire func()    // called on match
+	line []byte    // pending line
+}
+
+func (l *lineWatcher) Write(p []byte) (int, error) {
+	l.line = append(l.line, p...)
+	for {
+		i := bytes.IndexByte(l.line, '\n')
+		if i < 0 {
+			break
+		}
+		if _, ok := smart(strings.TrimSuffix(string(l.line[:i]), "\r"), l.want); ok {
+			l.fire()
+		}
+		l.line = l.line[i+1:]
+	}
+	return l.w.Write(p)
+}
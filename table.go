@@ -0,0 +1,202 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// tableSeparators holds the field separators of the supported table formats by
+// extension.
+var tableSeparators = map[string]rune{".csv": ',', ".tsv": '\t'}
+
+// maxTableProblems holds the maximum number of reported table differences.
+const maxTableProblems = 20
+
+// table represents a parsed table.
+type table struct {
+	header  []string       // column names
+	columns map[string]int // column indexes by name
+	rows    [][]string     // data rows
+}
+
+// parseTable parses the table with the specified field separator. The first
+// record holds the column names.
+func parseTable(data string, comma rune) (*table, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = comma == '\t'
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{columns: map[string]int{}}
+	if len(records) > 0 {
+		t.header, t.rows = records[0], records[1:]
+	}
+	for i, name := range t.header {
+		if _, ok := t.columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column: %s", name)
+		}
+		t.columns[name] = i
+	}
+	return t, nil
+}
+
+// cell returns the cell of the row in the named column, or an empty string if
+// missing.
+func (t *table) cell(row int, column string) string {
+	if i, ok := t.columns[column]; ok && i < len(t.rows[row]) {
+		return t.rows[row][i]
+	}
+	return ""
+}
+
+// compareTables returns the differences between the got and want tables.
+// Columns are matched by name and the ignored columns are skipped. If the key
+// column is not empty, rows are matched by their key regardless of order, else
+// by position. Rows are numbered from 1, excluding the header.
+func compareTables(got, want *table, ignore []string, key string) []string {
+	var problems []string
+
+	ignored := map[string]bool{}
+	for _, name := range ignore {
+		ignored[name] = true
+	}
+
+	var columns []string // compared columns
+	for _, name := range want.header {
+		if ignored[name] {
+			continue
+		}
+		if _, ok := got.columns[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing column: %q", name))
+			continue
+		}
+		columns = append(columns, name)
+	}
+	for _, name := range got.header {
+		if _, ok := want.columns[name]; !ok && !ignored[name] {
+			problems = append(problems, fmt.Sprintf("unexpected column: %q", name))
+		}
+	}
+
+	compareRows := func(label string, g, w int) {
+		for _, name := range columns {
+			if gotCell, wantCell := got.cell(g, name), want.cell(w, name); gotCell != wantCell {
+				problems = append(problems, fmt.Sprintf("%s, column %q: got %s, want %s", label, name, gotCell, wantCell))
+			}
+		}
+	}
+
+	switch {
+
+	case key == "":
+		for i := range want.rows {
+			if i < len(got.rows) {
+				compareRows(fmt.Sprintf("row %d", i+1), i, i)
+			} else {
+				problems = append(problems, fmt.Sprintf("missing row %d", i+1))
+			}
+		}
+		for i := len(want.rows); i < len(got.rows); i++ {
+			problems = append(problems, fmt.Sprintf("unexpected row %d", i+1))
+		}
+
+	case !hasColumn(got, key) || !hasColumn(want, key):
+		problems = append(problems, fmt.Sprintf("missing key column: %q", key))
+
+	default:
+		wanted := map[string]int{}
+		for i := range want.rows {
+			k := want.cell(i, key)
+			if _, ok := wanted[k]; ok {
+				problems = append(problems, fmt.Sprintf("duplicate wanted row (%s=%s)", key, k))
+			}
+			wanted[k] = i
+		}
+
+		found := map[string]bool{}
+		for i := range got.rows {
+			k := got.cell(i, key)
+			label := fmt.Sprintf("row %d (%s=%s)", i+1, key, k)
+			w, ok := wanted[k]
+			switch {
+			case found[k]:
+				problems = append(problems, "duplicate "+label)
+			case !ok:
+				problems = append(problems, "unexpected "+label)
+			default:
+				compareRows(label, i, w)
+			}
+			found[k] = true
+		}
+
+		for i := range want.rows {
+			if k := want.cell(i, key); !found[k] {
+				problems = append(problems, fmt.Sprintf("missing row (%s=%s)", key, k))
+				found[k] = true
+			}
+		}
+	}
+
+	if n := len(problems); n > maxTableProblems {
+		problems = append(problems[:maxTableProblems], fmt.Sprintf("and %d more differences", n-maxTableProblems))
+	}
+	return problems
+}
+
+// hasColumn reports whether the table has the named column.
+func hasColumn(t *table, name string) bool {
+	_, ok := t.columns[name]
+	return ok
+}
+
+// matchTable tests if the got table in the format with the specified extension
+// matches the gold master by rows and cells, as set up by the ignoreColumns
+// and keyColumn fields. If not, accumulates an error with the specified name.
+func (m *match) matchTable(field, got, ext string) {
+	comma := tableSeparators[ext]
+	name := field + " golden" + ext
+
+	gotTable, err := parseTable(got, comma)
+	if err != nil {
+		m.parseError(field, ext[1:], ext, got, name+" parse error:\ngot: "+err.Error())
+		return
+	}
+
+	want, ok := m.getGolden(name, ext, got)
+	if !ok {
+		return // file error
+	}
+
+	c := check{
+		Name:    field,
+		Matcher: ext[1:],
+		Got:     got,
+		Want:    want,
+		File:    m.goldenFile(ext),
+
+		diffable: true,
+	}
+
+	if wantTable, err := parseTable(want, comma); err != nil {
+		c.Message = name + " parse error:\nwant: " + err.Error()
+	} else {
+		problems := compareTables(gotTable, wantTable, m.ignoreColumns, m.keyColumn)
+		if c.Pass = len(problems) == 0; !c.Pass {
+			c.Message = name + " match error:\n" + strings.Join(problems, "\n")
+		}
+	}
+
+	m.setActual(name, c.File, got, c.Pass)
+	m.addCheck(c)
+}
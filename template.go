@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateExt holds the extension of the template gold masters.
+const templateExt = ".tmpl"
+
+// placeholderMark delimits the placeholders in the rendered template.
+const placeholderMark = "\x00"
+
+// compileTemplate renders the template with the specified variables and
+// returns the regular expression matching the rendered text, where the
+// placeholders output by the any and regex functions match any string and the
+// given pattern, respectively. The rendered text, with the placeholders as
+// written, is also returned.
+func compileTemplate(text string, vars map[string]interface{}) (*regexp.Regexp, string, error) {
+	var patterns, sources []string
+	placeholder := func(pattern, source string) string {
+		patterns = append(patterns, pattern)
+		sources = append(sources, source)
+		return placeholderMark + strconv.Itoa(len(patterns)-1) + placeholderMark
+	}
+
+	funcs := template.FuncMap{
+		"any": func() string {
+			return placeholder("(?s:.*?)", "{{any}}")
+		},
+		"regex": func(pattern string) (string, error) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return "", err
+			}
+			return placeholder("(?:"+pattern+")", "{{regex "+strconv.Quote(pattern)+"}}"), nil
+		},
+	}
+
+	t, err := template.New("golden").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, "", err
+	}
+	var b strings.Builder
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	if err := t.Execute(&b, vars); err != nil {
+		return nil, "", err
+	}
+
+	var expr, rendered strings.Builder
+	expr.WriteString("^")
+	for i, part := range strings.Split(b.String(), placeholderMark) {
+		if i%2 == 0 {
+			expr.WriteString(regexp.QuoteMeta(part))
+			rendered.WriteString(part)
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n >= len(patterns) {
+			return nil, "", fmt.Errorf("invalid placeholder: %q", part)
+		}
+		expr.WriteString(patterns[n])
+		rendered.WriteString(sources[n])
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, "", err
+	}
+	return re, rendered.String(), nil
+}
+
+// escapeTemplate returns a template rendering exactly the string s.
+func escapeTemplate(s string) string {
+	return strings.Replace(s, "{{", `{{"{{"}}`, -1)
+}
+
+// matchTemplate tests if the got string matches the template gold master
+// rendered with the variables of the match. If not, accumulates an error with
+// the specified name. The update flag keeps a template still matching the got
+// string, else replaces it with the got string, escaped.
+func (m *match) matchTemplate(field, got string) {
+	name := field + " golden" + templateExt
+	file := m.goldenFile(templateExt)
+
+	if *update {
+		data, err := ioutil.ReadFile(file)
+		keep := err == nil
+		if keep {
+			re, _, err := compileTemplate(string(data), m.vars)
+			keep = err == nil && re.MatchString(got)
+		}
+		if !keep && !m.writeGolden(name, file, escapeTemplate(got)) {
+			return // file error
+		}
+	}
+
+	want, ok := m.readGolden(name, file, escapeTemplate(got))
+	if !ok {
+		return // file error
+	}
+
+	re, rendered, err := compileTemplate(want, m.vars)
+	if err != nil {
+		m.messages = append(m.messages, name+" template error:\n"+err.Error())
+		return
+	}
+
+	c := check{
+		Name:    field,
+		Matcher: "template",
+		Got:     got,
+		Want:    rendered,
+		File:    file,
+		Pass:    re.MatchString(got),
+
+		diffable: true,
+	}
+	if !c.Pass {
+		c.Message = format(name, got, rendered)
+	}
+
+	m.setActual(name, c.File, escapeTemplate(got), c.Pass)
+	m.addCheck(c)
+}
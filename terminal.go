@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import "os"
+
+// Term holds the settings of a pseudo-terminal.
+type Term struct {
+	// Rows and Cols hold the window size. The default size is 24x80.
+	Rows, Cols uint16
+
+	// Screen, if true, renders the terminal output through a virtual screen
+	// and writes the final screen content, rather than the raw output stream,
+	// to the standard output.
+	Screen bool
+}
+
+// terminal implements a Runner for an external program running in a
+// pseudo-terminal.
+type terminal struct {
+	program
+	term Term // terminal settings
+}
+
+// Terminal returns a Runner for the named program running in a pseudo-terminal
+// with the specified process environment and terminal settings. The standard
+// input, output and error of the program are connected to the terminal: the
+// terminal output is written to the standard output of the Runner, while its
+// standard error is left empty. The standard input is followed by an end of
+// transmission, so that the program reads an end of file even for an empty
+// input. It is supported only on Linux.
+func Terminal(name string, env []string, term Term) Runner {
+	if term.Rows == 0 || term.Cols == 0 {
+		term.Rows, term.Cols = 24, 80
+	}
+	return &terminal{
+		program: program{
+			name: name,
+			env:  append(os.Environ(), env...),
+		},
+		term: term,
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/larhun/golden/internal/toml"
+	"github.com/larhun/golden/internal/yaml"
+)
+
+// treeFormat represents a structured format, such as a configuration or a
+// markup format, whose documents are compared as trees.
+type treeFormat struct {
+	name    string                               // format name
+	parse   func(string) (interface{}, error)    // parse function
+	format  func(interface{}) string             // canonical format function
+	compare func(got, want interface{}) []string // compare function
+}
+
+// treeFormats holds the supported structured formats by extension.
+var treeFormats = map[string]treeFormat{
+	".yaml": yamlFormat,
+	".yml":  yamlFormat,
+	".toml": {
+		name: "toml",
+		parse: func(s string) (interface{}, error) {
+			return toml.Parse(s)
+		},
+		format: func(v interface{}) string {
+			return toml.Format(v.(map[string]interface{}))
+		},
+		compare: func(got, want interface{}) []string {
+			return compareTree("", got, want, toml.Value)
+		},
+	},
+	".xml":   xmlFormat,
+	".html":  htmlFormat,
+	".jsonl": jsonlFormat,
+}
+
+// yamlFormat holds the YAML configuration format.
+var yamlFormat = treeFormat{
+	name:   "yaml",
+	parse:  yaml.Parse,
+	format: yaml.Format,
+	compare: func(got, want interface{}) []string {
+		return compareTree("", got, want, yaml.Scalar)
+	},
+}
+
+// bareKey holds the pattern of the keys that are not quoted in a path.
+var bareKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// keyPath returns the path of the key nested in the specified path.
+func keyPath(path, key string) string {
+	if !bareKey.MatchString(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// compareTree returns the differences between the got and want trees, each
+// one reported with the path of the differing value.
+func compareTree(path string, got, want interface{}, value func(interface{}) string) []string {
+	var problems []string
+	at := path
+	if at == "" {
+		at = "document"
+	}
+
+	switch w := want.(type) {
+
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(w))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if v, ok := g[k]; ok {
+				problems = append(problems, compareTree(keyPath(path, k), v, w[k], value)...)
+			} else {
+				problems = append(problems, "missing key: "+keyPath(path, k))
+			}
+		}
+
+		keys = keys[:0]
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			problems = append(problems, "unexpected key: "+keyPath(path, k))
+		}
+		return problems
+
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := range w {
+			item := fmt.Sprintf("%s[%d]", path, i)
+			if i < len(g) {
+				problems = append(problems, compareTree(item, g[i], w[i], value)...)
+			} else {
+				problems = append(problems, "missing item: "+item)
+			}
+		}
+		for i := len(w); i < len(g); i++ {
+			problems = append(problems, fmt.Sprintf("unexpected item: %s[%d]", path, i))
+		}
+		return problems
+
+	default:
+		if equalScalar(got, want) {
+			return nil
+		}
+	}
+
+	return []string{fmt.Sprintf("%s: got %s, want %s", at, value(got), value(want))}
+}
+
+// equalScalar reports whether the scalars are equal. Integer and float numbers
+// are compared by value, and NaN values are equal.
+func equalScalar(got, want interface{}) bool {
+	g, gok := toFloat(got)
+	w, wok := toFloat(want)
+	if gok && wok {
+		return g == w || math.IsNaN(g) && math.IsNaN(w)
+	}
+
+	switch got.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return got == want
+}
+
+// toFloat returns the number as a float and reports if succeeded.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// matchTree tests if the got document in the structured format with the
+// specified extension matches the gold master, ignoring formatting. If not,
+// accumulates an error with the specified name. The gold master is updated to
+// the canonical form of the got document, or to the got string if it cannot be
+// parsed.
+func (m *match) matchTree(field, got, ext string) {
+	f := treeFormats[ext]
+	name := field + " golden" + ext
+
+	gotTree, err := f.parse(got)
+	if err != nil {
+		m.parseError(field, f.name, ext, got, name+" parse error:\ngot: "+err.Error())
+		return
+	}
+	canonical := f.format(gotTree)
+
+	want, ok := m.getGolden(name, ext, canonical)
+	if !ok {
+		return // file error
+	}
+
+	c := check{
+		Name:    field,
+		Matcher: f.name,
+		Got:     canonical,
+		Want:    want,
+		File:    m.goldenFile(ext),
+
+		diffable: true,
+	}
+
+	if wantTree, err := f.parse(want); err != nil {
+		c.Message = name + " parse error:\nwant: " + err.Error()
+	} else {
+		c.Want = f.format(wantTree)
+		problems := f.compare(gotTree, wantTree)
+		if c.Pass = len(problems) == 0; !c.Pass {
+			c.Message = name + " match error:\n" + strings.Join(problems, "\n")
+		}
+	}
+
+	m.setActual(name, c.File, canonical, c.Pass)
+	m.addCheck(c)
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+package golden
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// processUsage returns the resources used by the exited process and reports if
+// available. The maximum resident set size is reported by Linux in kilobytes.
+func processUsage(state *os.ProcessState) (Usage, bool) {
+	r, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || r == nil {
+		return Usage{}, false
+	}
+	return Usage{
+		MaxRSS:  r.Maxrss * 1024,
+		CPUTime: time.Duration(r.Utime.Nano() + r.Stime.Nano()),
+	}, true
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2018 Larry Hunter <larhun.it@gmail.com>. All rights reserved.
+//
+// Use of this source code is governed by a BSD 3-Clause license that can be
+// found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package golden
+
+import "os"
+
+// processUsage reports that the resource usage is not supported.
+func processUsage(state *os.ProcessState) (Usage, bool) {
+	return Usage{}, false
+}